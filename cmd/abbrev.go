@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/pkg/errors"
+)
+
+const (
+	minAbbrev = 4
+	maxAbbrev = 40
+)
+
+// abbreviator shortens commit hashes to a fixed length or, when it knows
+// every object hash in the repository, to the shortest unambiguous prefix
+// that is at least that long.
+type abbreviator struct {
+	length int
+	hashes []string
+}
+
+func newAbbreviator(repo *git.Repository, length int, minimal bool) (*abbreviator, error) {
+	if length < minAbbrev || length > maxAbbrev {
+		return nil, errors.New(fmt.Sprintf("abbrev must be between %d and %d", minAbbrev, maxAbbrev))
+	}
+	a := &abbreviator{length: length}
+	if !minimal {
+		return a, nil
+	}
+
+	iter, err := repo.Storer.IterEncodedObjects(plumbing.AnyObject)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list objects")
+	}
+	err = iter.ForEach(func(obj plumbing.EncodedObject) error {
+		a.hashes = append(a.hashes, obj.Hash().String())
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list objects")
+	}
+	sort.Strings(a.hashes)
+	return a, nil
+}
+
+func (a *abbreviator) abbrev(hash string) string {
+	length := a.length
+	if a.hashes != nil {
+		// only the sorted neighbours can share a longer prefix with hash
+		i := sort.SearchStrings(a.hashes, hash)
+		if i > 0 {
+			length = max(length, commonPrefix(a.hashes[i-1], hash)+1)
+		}
+		if i+1 < len(a.hashes) {
+			length = max(length, commonPrefix(a.hashes[i+1], hash)+1)
+		}
+	}
+	return hash[:min(length, len(hash))]
+}
+
+func commonPrefix(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const testHash = "1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d"
+
+func TestAbbrevLength(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: first")
+
+	tests := []struct {
+		length  int
+		want    string
+		wantErr bool
+	}{
+		{length: 4, want: "1a2b"},
+		{length: 7, want: "1a2b3c4"},
+		{length: 12, want: "1a2b3c4d5e6f"},
+		{length: 40, want: testHash},
+		{length: 3, wantErr: true},
+		{length: 41, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.length), func(t *testing.T) {
+			a, err := newAbbreviator(r.repo, tt.length, false)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("got no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := a.abbrev(testHash); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAbbrevMinimal(t *testing.T) {
+	a := &abbreviator{length: 4, hashes: []string{
+		"1a2b3c0000000000000000000000000000000000",
+		testHash,
+		"9f00000000000000000000000000000000000000",
+	}}
+	tests := []struct {
+		hash, want string
+	}{
+		// shares 1a2b3c with its neighbour, so it needs one more character
+		{testHash, "1a2b3c4"},
+		{"9f00000000000000000000000000000000000000", "9f00"},
+	}
+	for _, tt := range tests {
+		if got := a.abbrev(tt.hash); got != tt.want {
+			t.Errorf("abbrev(%s) = %q, want %q", tt.hash, got, tt.want)
+		}
+	}
+}
+
+func TestAbbrevFlag(t *testing.T) {
+	r := newTestRepo(t)
+	hash := r.commit("feat: first")
+
+	out := runSumit(t, r.dir, "--abbrev", "10", "1.0.0")
+	if want := "- feat: first [" + hash.String()[:10] + "]"; !strings.Contains(out, want) {
+		t.Errorf("got\n%s\nwant a line %q", out, want)
+	}
+}
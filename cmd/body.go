@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-git/go-git/v5"
+)
+
+const defaultCommentChar = "#"
+
+// truncatedMarker ends a body cut short by --max-body-bytes.
+const truncatedMarker = "…(truncated)"
+
+// commentChar returns the character git uses to mark comment lines in commit
+// messages, as configured by core.commentChar. "auto" makes git pick one per
+// commit, so it falls back to the default like an unset value does.
+func commentChar(repo *git.Repository) string {
+	cfg, err := repo.Config()
+	if err != nil {
+		return defaultCommentChar
+	}
+	char := cfg.Raw.Section("core").Option("commentChar")
+	if char == "" || char == "auto" {
+		return defaultCommentChar
+	}
+	return char
+}
+
+// commitBody returns the message without its subject line, comment lines and
+// trailer block.
+func commitBody(message, commentChar string) string {
+	_, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	body = strings.TrimSpace(stripComments(body, commentChar))
+	if parseTrailers(message) != nil {
+		if i := strings.LastIndex(body, "\n\n"); i >= 0 {
+			body = body[:i]
+		} else {
+			body = ""
+		}
+	}
+	return strings.TrimSpace(body)
+}
+
+// stripComments drops the lines starting with commentChar, as git does when
+// cleaning up a commit message.
+func stripComments(message, commentChar string) string {
+	lines := strings.Split(message, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(line, commentChar) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// capBody cuts body down to at most max bytes, not counting the marker
+// added to show it was cut, without splitting a UTF-8 sequence. A max of 0
+// leaves the body alone.
+func capBody(body string, max int) string {
+	if max <= 0 || len(body) <= max {
+		return body
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return strings.TrimRight(body[:cut], " \t\n") + truncatedMarker
+}
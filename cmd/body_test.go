@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// setCommentChar sets core.commentChar in the repo's config.
+func setCommentChar(t *testing.T, r *testRepo, char string) {
+	t.Helper()
+	cfg, err := r.repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Raw.Section("core").SetOption("commentChar", char)
+	if err := r.repo.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommentChar(t *testing.T) {
+	tests := []struct {
+		name, config, want string
+	}{
+		{"unset", "", "#"},
+		{"custom", ";", ";"},
+		{"auto", "auto", "#"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepo(t)
+			if tt.config != "" {
+				setCommentChar(t, r, tt.config)
+			}
+			if got := commentChar(r.repo); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitBody(t *testing.T) {
+	message := "feat: a\n\nFirst line.\n# hash comment\n; semicolon comment\nLast line.\n\nSigned-off-by: Jane Doe <jane@example.com>"
+	tests := []struct {
+		commentChar, want string
+	}{
+		{"#", "First line.\n; semicolon comment\nLast line."},
+		{";", "First line.\n# hash comment\nLast line."},
+	}
+	for _, tt := range tests {
+		if got := commitBody(message, tt.commentChar); got != tt.want {
+			t.Errorf("commitBody with %q = %q, want %q", tt.commentChar, got, tt.want)
+		}
+	}
+}
+
+func TestWithBodyHonorsCommentChar(t *testing.T) {
+	r := newTestRepo(t)
+	setCommentChar(t, r, ";")
+	r.commit("feat: a\n\n#1 is the issue this closes.\n; a comment")
+
+	gen, head := testGenerator(t, r.dir, "--with-body")
+	if got, want := walkReleases(t, gen, head)[0].Changes[0].Body, "#1 is the issue this closes."; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestIncludeBodyForTypes(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a\n\nfeature body")
+	r.commit("chore: b\n\nchore body")
+	r.commit("fix!: c\n\nbreaking body")
+	r.commit(squashMessage)
+
+	tests := []struct {
+		name  string
+		types string
+		want  map[string]bool
+	}{
+		{"all types", "", map[string]bool{"feat: a": true, "chore: b": true, "fix!: c": true, "Add retries to the uploader": true}},
+		{"features", "feat", map[string]bool{"feat: a": true}},
+		{"breaking", "breaking", map[string]bool{"fix!: c": true}},
+		{"case and spacing", " FEAT , chore", map[string]bool{"feat: a": true, "chore: b": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := []string{"--with-body"}
+			if tt.types != "" {
+				args = append(args, "--include-body-for-types", tt.types)
+			}
+			gen, head := testGenerator(t, r.dir, args...)
+			for _, change := range walkReleases(t, gen, head)[0].Changes {
+				hasBody := change.Body != "" || len(change.Commits) > 0
+				if hasBody != tt.want[change.Title] {
+					t.Errorf("%q has body %v, want %v", change.Title, hasBody, tt.want[change.Title])
+				}
+			}
+		})
+	}
+}
+
+func TestCapBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		max  int
+		want string
+	}{
+		{"no cap", "a long body", 0, "a long body"},
+		{"under the cap", "short", 10, "short"},
+		{"at the cap", "exactly10!", 10, "exactly10!"},
+		{"cut", "first line\nsecond line", 11, "first line" + truncatedMarker},
+		{"multi-byte rune kept whole", "añb", 2, "a" + truncatedMarker},
+		{"emoji kept whole", "ok 🎉🎉", 5, "ok" + truncatedMarker},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := capBody(tt.body, tt.max)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("got invalid UTF-8 %q", got)
+			}
+		})
+	}
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a\n\n" + strings.Repeat("ü", 3000))
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"default cap", nil, 4096},
+		{"custom cap", []string{"--max-body-bytes", "100"}, 100},
+		{"no cap", []string{"--max-body-bytes", "0"}, 6000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, append([]string{"--with-body"}, tt.args...)...)
+			body := walkReleases(t, gen, head)[0].Changes[0].Body
+			if got := len(strings.TrimSuffix(body, truncatedMarker)); got != tt.want {
+				t.Errorf("got %d bytes of body, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+)
+
+// RefError is returned when a ref given to pick the commits can't be
+// resolved. It matches ErrInvalidRange as well as the error it wraps.
+type RefError struct {
+	Ref string
+	Err error
+}
+
+func (e *RefError) Error() string {
+	return fmt.Sprintf("failed to resolve %s: %s", e.Ref, e.Err)
+}
+
+func (e *RefError) Unwrap() []error {
+	return []error{ErrInvalidRange, e.Err}
+}
+
+// startRef resolves the ref the history is walked from: HEAD, or the given
+// branch, tag or commit, which doesn't have to be checked out.
+func startRef(repo *git.Repository, name string) (*plumbing.Reference, error) {
+	if name == "" {
+		ref, err := repo.Head()
+		if err == plumbing.ErrReferenceNotFound {
+			return nil, fmt.Errorf("%w: HEAD doesn't point to a commit yet", ErrNoCommits)
+		} else if err != nil {
+			return nil, errors.Wrap(err, "failed to get head ref")
+		}
+		return ref, nil
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(name))
+	if err != nil {
+		return nil, &RefError{Ref: name, Err: err}
+	}
+	// keep the branch name when it is one, so its upstream remote is linked
+	refName := plumbing.NewBranchReferenceName(name)
+	if _, err := repo.Reference(refName, false); err != nil {
+		refName = plumbing.ReferenceName(name)
+	}
+	return plumbing.NewHashReference(refName, *hash), nil
+}
+
+// mergeBases finds the commits where the history of head diverged from the
+// base ref, which can be a branch, remote branch, tag or hash.
+func mergeBases(repo *git.Repository, head plumbing.Hash, base string) ([]plumbing.Hash, error) {
+	baseHash, err := repo.ResolveRevision(plumbing.Revision(base))
+	if err != nil {
+		return nil, &RefError{Ref: base, Err: err}
+	}
+	baseCommit, err := repo.CommitObject(*baseHash)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get base commit")
+	}
+	headCommit, err := repo.CommitObject(head)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get head commit")
+	}
+
+	bases, err := headCommit.MergeBase(baseCommit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compute merge base")
+	}
+	hashes := make([]plumbing.Hash, len(bases))
+	for i, c := range bases {
+		hashes[i] = c.Hash
+	}
+	return hashes, nil
+}
+
+// defaultBranch finds the branch --branch-only compares against when --base
+// isn't given: the one origin's HEAD points to, falling back to a local main
+// or master branch.
+func defaultBranch(repo *git.Repository) (string, error) {
+	ref, err := repo.Reference(plumbing.NewRemoteHEADReferenceName("origin"), false)
+	if err == nil && ref.Type() == plumbing.SymbolicReference {
+		return ref.Target().String(), nil
+	}
+	for _, name := range []string{"main", "master"} {
+		if _, err := repo.Reference(plumbing.NewBranchReferenceName(name), false); err == nil {
+			return name, nil
+		}
+	}
+	return "", errors.New("failed to find the default branch; pass --base")
+}
+
+// ancestors collects the commit ref resolves to and every commit reachable
+// from it, so the walk can leave out all of the history up to ref.
+func ancestors(repo *git.Repository, ref string) (map[plumbing.Hash]bool, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, &RefError{Ref: ref, Err: err}
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to get commit %s", ref))
+	}
+
+	seen := make(map[plumbing.Hash]bool)
+	err = object.NewCommitPreorderIter(commit, nil, nil).ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to walk the history of %s", ref))
+	}
+	return seen, nil
+}
+
+// initialCommitRef is the --compare-from-initial value, and the one it takes
+// when it's given without one, that stands for the repository's first commit.
+const initialCommitRef = "initial"
+
+// initialCommit follows the first parents of from back to the commit that
+// started the history.
+func initialCommit(repo *git.Repository, from plumbing.Hash) (plumbing.Hash, error) {
+	commit, err := repo.CommitObject(from)
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "failed to get commit log")
+	}
+	for commit.NumParents() > 0 {
+		if commit, err = commit.Parent(0); err != nil {
+			return plumbing.ZeroHash, errors.Wrap(err, "failed to find the first commit")
+		}
+	}
+	return commit.Hash, nil
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestBranchOnly(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: on master")
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("topic"), Create: true}); err != nil {
+		t.Fatal(err)
+	}
+	r.commit("fix: on topic")
+	r.commit("feat: also on topic")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"whole history", nil, []string{"feat: also on topic", "fix: on topic", "feat: on master"}},
+		{"since master", []string{"--branch-only", "--base", "master"}, []string{"feat: also on topic", "fix: on topic"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := runSumit(t, r.dir, append(tt.args, "1.0.0")...)
+			if got := changeTitles(out); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	out := runSumitError(t, r.dir, "--branch-only", "--base", "missing", "1.0.0")
+	if !strings.Contains(out, "failed to resolve missing") {
+		t.Errorf("got %q, want a base resolution error", out)
+	}
+}
+
+func TestDefaultBranch(t *testing.T) {
+	tests := []struct {
+		name     string
+		branches []string
+		originTo string
+		want     string
+	}{
+		{"master", []string{"master"}, "", "master"},
+		{"main before master", []string{"master", "main"}, "", "main"},
+		{"origin HEAD", []string{"master", "main"}, "refs/remotes/origin/trunk", "refs/remotes/origin/trunk"},
+		{"none", []string{"develop"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepo(t)
+			head := r.commit("feat: first")
+			// PlainInit points HEAD at master, which only exists once
+			// committed to
+			if err := r.repo.Storer.RemoveReference(plumbing.Master); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range tt.branches {
+				setRef(t, r, plumbing.NewHashReference(plumbing.NewBranchReferenceName(name), head))
+			}
+			if tt.originTo != "" {
+				setRef(t, r, plumbing.NewHashReference(plumbing.ReferenceName(tt.originTo), head))
+				setRef(t, r, plumbing.NewSymbolicReference(plumbing.NewRemoteHEADReferenceName("origin"), plumbing.ReferenceName(tt.originTo)))
+			}
+
+			got, err := defaultBranch(r.repo)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("got %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func setRef(t *testing.T, r *testRepo, ref *plumbing.Reference) {
+	t.Helper()
+	if err := r.repo.Storer.SetReference(ref); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBranchOnlyAgainstMaster(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: on master")
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("topic"), Create: true}); err != nil {
+		t.Fatal(err)
+	}
+	r.commit("fix: on topic")
+
+	gen, head := testGenerator(t, r.dir, "--branch-only")
+	if got := titles(walkReleases(t, gen, head)[0].Changes); len(got) != 1 || got[0] != "fix: on topic" {
+		t.Errorf("got %q, want only the topic commit", got)
+	}
+}
+
+func TestRef(t *testing.T) {
+	r := newTestRepo(t)
+	first := r.commit("feat: a")
+	r.tag("v1.0.0")
+	second := r.commit("feat: b")
+	setRef(t, r, plumbing.NewHashReference(plumbing.NewBranchReferenceName("feature"), second))
+	r.reset(first)
+
+	tests := []struct {
+		ref  string
+		want [][]string
+	}{
+		{"", [][]string{{"1.0.0", "feat: a"}}},
+		{"feature", [][]string{{"Unreleased", "feat: b"}, {"1.0.0", "feat: a"}}},
+		{second.String()[:7], [][]string{{"Unreleased", "feat: b"}, {"1.0.0", "feat: a"}}},
+		{"v1.0.0", [][]string{{"1.0.0", "feat: a"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, "--ref", tt.ref)
+			var got [][]string
+			for _, release := range walkReleases(t, gen, head) {
+				got = append(got, append([]string{release.Version}, titles(release.Changes)...))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	_, _, err := newTestGenerator(t, r.dir, "--ref", "missing")
+	var refErr *RefError
+	if !errors.As(err, &refErr) || refErr.Ref != "missing" {
+		t.Errorf("got %v, want a RefError for missing", err)
+	}
+}
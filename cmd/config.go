@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is read from --dir for defaults of the command-line flags.
+const configFile = ".sumit.yaml"
+
+func init() {
+	// set here rather than in rootCmd, which loadConfig refers to
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		bail(loadConfig())
+	}
+	// cobra checks the arguments before PersistentPreRun, and the config
+	// can make the version optional
+	validateArgs := rootCmd.Args
+	rootCmd.Args = func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return err
+		}
+		return validateArgs(cmd, args)
+	}
+}
+
+// configured holds the names of the flags set by the config file, once
+// it's been loaded.
+var configured map[string]bool
+
+// flagChanged reports whether a flag was given on the command line or set
+// in the config file.
+func flagChanged(cmd *cobra.Command, name string) bool {
+	return cmd.Flags().Changed(name) || configured[name]
+}
+
+// loadConfig sets the flags named in the config file, if there is one, to
+// its values. Flags given on the command line keep their values, and the
+// config doesn't count as setting a flag, so it can't conflict with one
+// that's mutually exclusive with it; flagChanged sees it though. It's only
+// loaded once, however many times it's called.
+func loadConfig() error {
+	if configured != nil {
+		return nil
+	}
+	configured = make(map[string]bool)
+	dir, _ := rootCmd.PersistentFlags().GetString("dir")
+	path := filepath.Join(dir, configFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	var settings map[string]any
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return errors.Wrapf(err, "failed to parse %s", path)
+	}
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		flag := rootCmd.PersistentFlags().Lookup(name)
+		if flag == nil || name == "dir" {
+			return errors.New(fmt.Sprintf("unknown setting in %s: %s", path, name))
+		}
+		if flag.Changed {
+			continue
+		}
+		values := []any{settings[name]}
+		if list, ok := settings[name].([]any); ok {
+			// repeatable flags are set once per item
+			values = list
+		}
+		for _, value := range values {
+			if err := flag.Value.Set(fmt.Sprint(value)); err != nil {
+				return errors.Wrapf(err, "invalid %s in %s", name, path)
+			}
+		}
+		configured[name] = true
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigSetsFlagDefaults(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: first")
+	r.tag("v0.1.0")
+	r.commit("fix: second")
+	r.tag("v0.2.0")
+	pinNow(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name   string
+		config string
+		args   []string
+		want   []string
+	}{
+		{
+			name:   "all-tags makes the version optional",
+			config: "all-tags: true\n",
+			want:   []string{"## [0.2.0] - 2024-01-01", "- fix: second", "## [0.1.0] - 2024-01-01", "- feat: first"},
+		},
+		{
+			name:   "tag-name makes the version optional",
+			config: "tag-name: v0.1.0\n",
+			want:   []string{"## [0.1.0] - 2024-06-01", "- feat: first"},
+		},
+		{
+			name:   "the command line wins",
+			config: "no-version-brackets: true\ntag-name: v0.1.0\n",
+			args:   []string{"--tag-name", "v0.2.0"},
+			want:   []string{"## 0.2.0 - 2024-06-01", "- fix: second"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.writeFile(configFile, tt.config)
+			out := runSumit(t, r.dir, tt.args...)
+			var got []string
+			for _, line := range lines(out) {
+				if strings.HasPrefix(line, "- ") {
+					// hashes differ between runs
+					line = strings.SplitN(line, " [", 2)[0]
+				}
+				got = append(got, line)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestConfigDedupeBy(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("fix: first take (#7)")
+	r.commit("fix: second take (#7)")
+	r.writeFile(configFile, "dedupe-by: pr\n")
+
+	gen, head := testGenerator(t, r.dir)
+	releases := walkReleases(t, gen, head)
+	if got := titles(releases[0].Changes); len(got) != 1 || got[0] != "fix: second take (#7)" {
+		t.Errorf("got %q, want only the newer change", got)
+	}
+}
+
+func TestConfigRejectsUnknownSettings(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: first")
+	r.writeFile(configFile, "all-tag: true\n")
+
+	_, _, err := newTestGenerator(t, r.dir)
+	if err == nil || !strings.Contains(err.Error(), "unknown setting") {
+		t.Errorf("got %v, want an unknown setting error", err)
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import "strings"
+
+// confluenceTemplate renders Confluence wiki markup, for pasting into the
+// wiki markup editor of a Confluence page. Bodies go in noformat blocks,
+// since a line break would end the list they're in.
+const confluenceTemplate = `{{ define "change" }}* {{ if .ScopeBadge }}{{ code .Scope }} {{ end }}{{ linkIssues (escape .Title) .Issues }}{{ if .SHA }} ({{ link .SHA .URL }}){{ end }}{{ with .Stats }} ({{ . }}){{ end }}{{ with .Branch }} (in {{ escape . }}){{ end }}{{ template "details" . }}{{ end -}}
+{{ define "details" }}{{ range .Commits }}
+** {{ escape . }}{{ end }}{{ range .Submodules }}
+** {{ escape .Path }}:{{ range .Changes }}
+*** {{ escape .Title }} ({{ link .SHA .URL }}){{ end }}{{ end }}{{ if .Body }}
+{noformat}
+{{ .Body }}
+{noformat}{{ end }}{{ end -}}
+h2. {{ escape .Version }} - {{ .Date }}
+{{ if .Groups }}{{ range .Groups }}
+h3. {{ escape .Title }}
+{{ if .Groups }}{{ range .Groups }}
+h4. {{ escape .Title }}
+{{ range .Changes }}{{ template "change" . }}
+{{ end }}{{ end }}{{ else }}{{ range .Changes }}{{ template "change" . }}
+{{ else }}* No notable changes
+{{ end }}{{ end }}{{ end }}{{ else }}
+{{ range .Changes }}{{ template "change" . }}
+{{ else }}* No notable changes
+{{ end }}{{ end }}{{ if .Domains }}
+h3. Changes by email domain
+{{ range .Domains }}* {{ escape .Domain }}: {{ .Count }}
+{{ end }}{{ end }}{{ if .CompareURL }}
+{{ link "Full Changelog" .CompareURL }}
+{{ end }}`
+
+// confluenceEscaper escapes the characters that start links, macros,
+// tables and text effects in wiki markup.
+var confluenceEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"[", `\[`,
+	"]", `\]`,
+	"{", `\{`,
+	"}", `\}`,
+	"|", `\|`,
+	"*", `\*`,
+	"_", `\_`,
+	"!", `\!`,
+)
+
+// confluenceLink renders a link in wiki markup's [text|url] syntax.
+func confluenceLink(text, url string) string {
+	text = confluenceEscaper.Replace(text)
+	if url == "" {
+		return text
+	}
+	return "[" + text + "|" + url + "]"
+}
+
+// confluenceCode formats text as monospace.
+func confluenceCode(text string) string {
+	return "{{" + text + "}}"
+}
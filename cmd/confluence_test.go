@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfluenceLink(t *testing.T) {
+	tests := []struct {
+		text, url string
+		want      string
+	}{
+		{"1a2b3c4", "https://example.com/c/1a2b3c4", "[1a2b3c4|https://example.com/c/1a2b3c4]"},
+		{"a [b|c]", "https://example.com", `[a \[b\|c\]|https://example.com]`},
+		{"no url", "", "no url"},
+		{"*bold* _it_", "", `\*bold\* \_it\_`},
+	}
+	for _, tt := range tests {
+		if got := confluenceLink(tt.text, tt.url); got != tt.want {
+			t.Errorf("confluenceLink(%q, %q) = %q, want %q", tt.text, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestConfluenceWiki(t *testing.T) {
+	got := renderFormat(t, "confluence-wiki", sampleRelease())
+	want := `h2. 1.1.0 - 2024-01-02
+
+h3. Features
+* feat(api): add pagination (#42) ([1a2b3c4|https://github.com/acme/widget/commits/1a2b3c4d5e6f]) (3 files, +42 -7) (in v1.2.0)
+{noformat}
+Lists now return a cursor for the next page.
+{noformat}
+
+h3. Bug Fixes
+* fix\!: reject empty names ([5d6e7f8|https://github.com/acme/widget/commits/5d6e7f8a9b0c])
+** validate names
+** update tests
+
+[Full Changelog|https://github.com/acme/widget/compare/v1.0.0...v1.1.0]
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	release := &Release{Version: "2.0.0", Date: "2024-02-01", Changes: []Change{
+		{Title: "feat: add {macro} API-7", Scope: "api", ScopeBadge: true, Issues: []Issue{{Key: "API-7", URL: "https://jira.example.com/browse/API-7"}}},
+	}}
+	got = renderFormat(t, "confluence-wiki", release)
+	if want := `* {{api}} feat: add \{macro\} [API-7|https://jira.example.com/browse/API-7]`; !strings.Contains(got, want) {
+		t.Errorf("got\n%s\nwant a line %q", got, want)
+	}
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"sort"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// containingRef is a release tag or local branch with every commit
+// reachable from it.
+type containingRef struct {
+	name    string
+	tag     bool
+	tipDate time.Time
+	commits map[plumbing.Hash]bool
+}
+
+// containingRefs finds the tag or branch a commit first appeared on, like
+// git describe --contains, for --show-branch.
+type containingRefs []containingRef
+
+// newContainingRefs walks the history of every release tag and local
+// branch, which takes a while in large repositories. Tags come first, and
+// refs whose tips are older come before newer ones, since the oldest ref
+// that contains a commit is where it landed.
+func newContainingRefs(repo *git.Repository, taggedCommits map[string][]string) (containingRefs, error) {
+	var refs containingRefs
+	add := func(name, revision string, hash plumbing.Hash, tag bool) error {
+		tip, err := repo.CommitObject(hash)
+		if err != nil {
+			return nil
+		}
+		commits, err := ancestors(repo, revision)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, containingRef{name: name, tag: tag, tipDate: tip.Committer.When, commits: commits})
+		return nil
+	}
+
+	for hash, tags := range taggedCommits {
+		if err := add(tags[0], "refs/tags/"+tags[0], plumbing.NewHash(hash), true); err != nil {
+			return nil, err
+		}
+	}
+	branches, err := repo.Branches()
+	if err != nil {
+		return nil, err
+	}
+	err = branches.ForEach(func(ref *plumbing.Reference) error {
+		return add(ref.Name().Short(), ref.Name().String(), ref.Hash(), false)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		switch {
+		case refs[i].tag != refs[j].tag:
+			return refs[i].tag
+		case !refs[i].tipDate.Equal(refs[j].tipDate):
+			return refs[i].tipDate.Before(refs[j].tipDate)
+		}
+		return refs[i].name < refs[j].name
+	})
+	return refs, nil
+}
+
+// lookup returns the first ref that contains the commit, or "" when none
+// does.
+func (refs containingRefs) lookup(hash plumbing.Hash) string {
+	for _, ref := range refs {
+		if ref.commits[hash] {
+			return ref.name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestContainingRefs(t *testing.T) {
+	r := newTestRepo(t)
+	a := r.commit("feat: a")
+	r.tag("v1.0.0")
+	b := r.commit("feat: b")
+	r.tag("v1.1.0")
+	c := r.commit("feat: c")
+	r.reset(b)
+	d := r.commit("feat: d")
+	setRef(t, r, plumbing.NewHashReference(plumbing.NewBranchReferenceName("feature"), d))
+	r.reset(c)
+	// an older branch that contains a tagged commit still loses to the tag
+	setRef(t, r, plumbing.NewHashReference(plumbing.NewBranchReferenceName("old"), a))
+
+	tagged, err := getTaggedCommits(r.repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs, err := newContainingRefs(r.repo, tagged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		hash plumbing.Hash
+		want string
+	}{
+		{"in both tags", a, "v1.0.0"},
+		{"in the newer tag", b, "v1.1.0"},
+		{"only on master", c, "master"},
+		{"only on a branch", d, "feature"},
+		{"on no ref", plumbing.NewHash("0123456789abcdef0123456789abcdef01234567"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := refs.lookup(tt.hash); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShowBranch(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a")
+	r.tag("v1.0.0")
+	r.commit("feat: b")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"off", nil, []string{"", ""}},
+		{"on", []string{"--show-branch"}, []string{"master", "v1.0.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			var got []string
+			for _, release := range walkReleases(t, gen, head) {
+				for _, change := range release.Changes {
+					got = append(got, change.Branch)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	out := runSumit(t, r.dir, "--show-branch", "--all-tags")
+	for _, want := range []string{"- feat: b [", "(in master)", "(in v1.0.0)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output doesn't have %q:\n%s", want, out)
+		}
+	}
+}
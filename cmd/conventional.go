@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	conventionalPattern = regexp.MustCompile(`^(\w+)(?:\(([^()]*)\))?(!)?: (.+)$`)
+	// untypedScopePattern only allows scopes without spaces, so prose such
+	// as "(see below): ..." isn't mistaken for a scope.
+	untypedScopePattern = regexp.MustCompile(`^\(([\w./-]+)\)(!)?: (.+)$`)
+)
+
+type ConventionalCommit struct {
+	Type        string
+	Scope       string
+	Breaking    bool
+	Description string
+}
+
+// parseConventional splits a conventional commit subject such as
+// "feat(api)!: drop v1 endpoints" into its parts. The second return value
+// reports whether the subject follows the convention at all.
+func parseConventional(subject string) (ConventionalCommit, bool) {
+	m := conventionalPattern.FindStringSubmatch(strings.TrimSpace(subject))
+	if m == nil {
+		return ConventionalCommit{}, false
+	}
+	return ConventionalCommit{
+		Type:        strings.ToLower(m[1]),
+		Scope:       m[2],
+		Breaking:    m[3] == "!",
+		Description: m[4],
+	}, true
+}
+
+// parseUntypedScope recognizes subjects such as "(api): fix pagination"
+// that have a conventional scope but no type. The commit it returns has no
+// type.
+func parseUntypedScope(subject string) (ConventionalCommit, bool) {
+	m := untypedScopePattern.FindStringSubmatch(strings.TrimSpace(subject))
+	if m == nil {
+		return ConventionalCommit{}, false
+	}
+	return ConventionalCommit{
+		Scope:       m[1],
+		Breaking:    m[2] == "!",
+		Description: m[3],
+	}, true
+}
+
+// hasBreakingFooter reports whether a commit message carries a
+// "BREAKING CHANGE:" footer.
+func hasBreakingFooter(message string) bool {
+	for _, line := range strings.Split(message, "\n") {
+		if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+			return true
+		}
+	}
+	return false
+}
+
+// lintExemptPattern matches the subjects git writes itself for merges and
+// reverts, which commitlint leaves alone as well.
+var lintExemptPattern = regexp.MustCompile(`^(Merge (pull request|branch|remote-tracking branch|tag) |Merge .+ into |Revert ")`)
+
+// nonConventional lists the changes that aren't conventional commits, for
+// the commitlint-check format. Merge and revert commits are skipped, and so
+// are hand-written entries, which have no commit to fix.
+func nonConventional(changes []Change) []Change {
+	var failed []Change
+	for _, change := range changes {
+		if !change.Conventional && !change.Fragment && !lintExemptPattern.MatchString(change.Title) {
+			failed = append(failed, change)
+		}
+	}
+	return failed
+}
+
+// checkConventional fails when any of the changes isn't a conventional
+// commit, for the commitlint-check format.
+func checkConventional(changes []Change) error {
+	failed := len(nonConventional(changes))
+	if failed == 0 {
+		return nil
+	}
+	commits := 0
+	for _, change := range changes {
+		if !change.Fragment {
+			commits++
+		}
+	}
+	return errors.New(fmt.Sprintf("%d of %d commits don't follow the conventional commit format", failed, commits))
+}
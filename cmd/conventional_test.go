@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckConventional(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes []Change
+		wantErr string
+	}{
+		{"none", nil, ""},
+		{"all conforming", []Change{{Conventional: true}, {Conventional: true}}, ""},
+		{"some failing", []Change{{Conventional: true}, {}, {}}, "2 of 3 commits"},
+		{"merge and revert", []Change{
+			{Title: "Merge branch 'main' into topic"},
+			{Title: "Merge pull request #12 from acme/topic"},
+			{Title: "Merge remote-tracking branch 'origin/main'"},
+			{Title: "Merge tag 'v1.0.0'"},
+			{Title: `Revert "feat: add search"`},
+		}, ""},
+		{"hand-written entries", []Change{{Conventional: true}, {Title: "Rewrote the guide", Fragment: true}, {}}, "1 of 2 commits"},
+		{"merge-like subject", []Change{{Title: "Merge the two parsers"}, {Title: "Revert to the old parser"}}, "2 of 2 commits"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkConventional(tt.changes)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("got %v, want no error", err)
+			} else if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommitlintCheckFormat(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: conforming")
+	r.commit("Update README")
+	r.commit("fix(api): conforming too")
+	r.commit("fix:missing space")
+	r.commit("Merge branch 'topic'")
+	r.commit(`Revert "feat: conforming"`)
+
+	gen, head := testGenerator(t, r.dir)
+	release := walkReleases(t, gen, head)[0]
+	out := renderFormat(t, "commitlint-check", release)
+	want := []string{
+		release.Changes[2].SHA + " fix:missing space",
+		release.Changes[4].SHA + " Update README",
+	}
+	if got := lines(out); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if err := checkConventional(release.Changes); err == nil {
+		t.Error("got no error for non-conforming commits")
+	}
+}
+
+func TestCommitlintCheckPasses(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: conforming")
+	r.commit("fix(api)!: conforming too")
+
+	if out := runSumit(t, r.dir, "--output-format", "commitlint-check", "1.0.0"); strings.TrimSpace(out) != "" {
+		t.Errorf("got output %q, want none", out)
+	}
+}
+
+func TestParseConventional(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    ConventionalCommit
+		ok      bool
+	}{
+		{"feat: add search", ConventionalCommit{Type: "feat", Description: "add search"}, true},
+		{"Fix(api): handle nil", ConventionalCommit{Type: "fix", Scope: "api", Description: "handle nil"}, true},
+		{"feat(api)!: drop v1", ConventionalCommit{Type: "feat", Scope: "api", Breaking: true, Description: "drop v1"}, true},
+		{"refactor!: rename", ConventionalCommit{Type: "refactor", Breaking: true, Description: "rename"}, true},
+		{"  chore: padded  ", ConventionalCommit{Type: "chore", Description: "padded"}, true},
+		{"fix:missing space", ConventionalCommit{}, false},
+		{"feat(a(b)): nested", ConventionalCommit{}, false},
+		{"Update README", ConventionalCommit{}, false},
+		{"(api): no type", ConventionalCommit{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseConventional(tt.subject)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseConventional(%q) = %+v, %v, want %+v, %v", tt.subject, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseUntypedScope(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    ConventionalCommit
+		ok      bool
+	}{
+		{"(api): fix thing", ConventionalCommit{Scope: "api", Description: "fix thing"}, true},
+		{"(web/ui)!: drop IE", ConventionalCommit{Scope: "web/ui", Breaking: true, Description: "drop IE"}, true},
+		{"(v1.2-beta_3): bump", ConventionalCommit{Scope: "v1.2-beta_3", Description: "bump"}, true},
+		{"  (api): padded  ", ConventionalCommit{Scope: "api", Description: "padded"}, true},
+		// prose and other near misses
+		{"(see below): details", ConventionalCommit{}, false},
+		{"(): empty scope", ConventionalCommit{}, false},
+		{"(api):no space", ConventionalCommit{}, false},
+		{"(api) fix thing", ConventionalCommit{}, false},
+		{"feat(api): typed", ConventionalCommit{}, false},
+		{"Update (api): docs", ConventionalCommit{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseUntypedScope(tt.subject)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseUntypedScope(%q) = %+v, %v, want %+v, %v", tt.subject, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestUntypedScopes(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat(cli): typed")
+	r.commit("(api)!: untyped")
+	r.commit("(see below): prose")
+
+	tests := []struct {
+		name string
+		args []string
+		want []Change
+	}{
+		{"off", nil, []Change{
+			{Title: "(see below): prose"},
+			{Title: "(api)!: untyped"},
+			{Title: "feat(cli): typed", Type: "feat", Scope: "cli", Conventional: true},
+		}},
+		{"on", []string{"--untyped-scopes"}, []Change{
+			{Title: "(see below): prose"},
+			{Title: "(api)!: untyped", Scope: "api", Breaking: true},
+			{Title: "feat(cli): typed", Type: "feat", Scope: "cli", Conventional: true},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			var got []Change
+			for _, change := range walkReleases(t, gen, head)[0].Changes {
+				got = append(got, Change{Title: change.Title, Type: change.Type, Scope: change.Scope, Breaking: change.Breaking, Conventional: change.Conventional})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+var csvHeader = []string{"SHA", "Date", "Author", "Type", "Subject", "URL"}
+
+// csvRenderer writes a release's changes as CSV rows, for spreadsheets and
+// reporting tools, after a header row when header is set. It's used as a
+// pointer so the header can be turned off once the first release is written.
+type csvRenderer struct {
+	header bool
+}
+
+func (r *csvRenderer) Execute(w io.Writer, data any) error {
+	release := data.(*Release)
+	cw := csv.NewWriter(w)
+	if r.header {
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	for _, change := range release.Changes {
+		row := []string{change.SHA, change.Date, change.Author, change.Type, change.Title, change.URL}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
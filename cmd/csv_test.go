@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+)
+
+func TestCSVQuoting(t *testing.T) {
+	release := &Release{Changes: []Change{
+		{SHA: "1a2b3c4", Date: "2024-01-01", Author: "Doe, Jane", Type: "feat", Title: `feat: add "quoted", comma`, URL: "https://example.com/1a2b3c4"},
+		{SHA: "5d6e7f8", Date: "2024-01-02", Author: "John Roe", Title: "multi\nline"},
+	}}
+	tests := []struct {
+		name   string
+		header bool
+		want   [][]string
+	}{
+		{"header", true, [][]string{
+			csvHeader,
+			{"1a2b3c4", "2024-01-01", "Doe, Jane", "feat", `feat: add "quoted", comma`, "https://example.com/1a2b3c4"},
+			{"5d6e7f8", "2024-01-02", "John Roe", "", "multi\nline", ""},
+		}},
+		{"no header", false, [][]string{
+			{"1a2b3c4", "2024-01-01", "Doe, Jane", "feat", `feat: add "quoted", comma`, "https://example.com/1a2b3c4"},
+			{"5d6e7f8", "2024-01-02", "John Roe", "", "multi\nline", ""},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := (&csvRenderer{header: tt.header}).Execute(&buf, release); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Contains(buf.Bytes(), []byte(`"feat: add ""quoted"", comma"`)) {
+				t.Errorf("subject isn't quoted:\n%s", buf.String())
+			}
+			got, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVSingleHeader(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a")
+	r.tag("v1.0.0")
+	r.commit("fix: b")
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"all tags", []string{"--all-tags"}, 1},
+		{"no header", []string{"--all-tags", "--no-header"}, 0},
+		{"wrapped renderers", []string{"--all-tags", "--wrap", "40", "--normalize-whitespace"}, 1},
+		{"wrapped renderers without header", []string{"--all-tags", "--no-header", "--normalize-whitespace"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := runSumit(t, r.dir, append([]string{"--output-format", "csv"}, tt.args...)...)
+			rows, err := csv.NewReader(bytes.NewBufferString(out)).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			headers := 0
+			for _, row := range rows {
+				if reflect.DeepEqual(row, csvHeader) {
+					headers++
+				}
+			}
+			if headers != tt.want || len(rows) != 2+tt.want {
+				t.Errorf("got %d rows with %d headers, want 2 changes and %d headers:\n%s", len(rows), headers, tt.want, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// discordMessageLimit is the most characters a Discord message can hold.
+const discordMessageLimit = 2000
+
+// discordDelimiter is the line written between the messages a release is
+// split into.
+const discordDelimiter = "--- 8< ---\n"
+
+var discordEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "|", `\|`)
+
+// discordLink renders a markdown link with its URL in angle brackets, which
+// keeps Discord from adding a preview embed for every commit.
+func discordLink(text, url string) string {
+	if url == "" {
+		return text
+	}
+	return "[" + text + "](<" + url + ">)"
+}
+
+// chunkRenderer splits whatever the wrapped renderer produces into messages
+// of at most limit characters, separated by discordDelimiter.
+type chunkRenderer struct {
+	renderer
+	limit int
+}
+
+func (r chunkRenderer) Execute(w io.Writer, data any) error {
+	var buf bytes.Buffer
+	if err := r.renderer.Execute(&buf, data); err != nil {
+		return err
+	}
+	chunks := splitChunks(buf.String(), r.limit)
+	_, err := io.WriteString(w, strings.Join(chunks, discordDelimiter))
+	return err
+}
+
+// splitChunks breaks text into chunks of at most limit characters, at line
+// boundaries where it can. A line too long for a chunk of its own is cut
+// into pieces that each end with a newline.
+func splitChunks(text string, limit int) []string {
+	var chunks []string
+	var chunk strings.Builder
+	size := 0
+	flush := func() {
+		if size > 0 {
+			chunks = append(chunks, chunk.String())
+			chunk.Reset()
+			size = 0
+		}
+	}
+	for _, line := range strings.SplitAfter(text, "\n") {
+		n := utf8.RuneCountInString(line)
+		if size+n > limit {
+			flush()
+		}
+		for n > limit {
+			cut := runeOffset(line, limit-1)
+			chunks = append(chunks, line[:cut]+"\n")
+			line = line[cut:]
+			n -= limit - 1
+		}
+		chunk.WriteString(line)
+		size += n
+	}
+	flush()
+	return chunks
+}
+
+// runeOffset is the byte offset of the n-th rune in s.
+func runeOffset(s string, n int) int {
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return len(s)
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitChunks(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{"fits", "a\nb\n", 10, []string{"a\nb\n"}},
+		{"split at lines", "aaa\nbbb\nccc\n", 8, []string{"aaa\nbbb\n", "ccc\n"}},
+		{"long line cut", "abcdefghij\n", 4, []string{"abc\n", "def\n", "ghi\n", "j\n"}},
+		{"runes counted", "ééé\nüüü\n", 4, []string{"ééé\n", "üüü\n"}},
+		{"empty", "", 10, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitChunks(tt.text, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscordFormat(t *testing.T) {
+	release := sampleRelease()
+	release.Changes, release.Groups = nil, nil
+	for i := 0; i < 60; i++ {
+		release.Changes = append(release.Changes, Change{
+			SHA:   "1a2b3c4",
+			Title: "feat: a change with *stars* and a long enough subject to fill messages",
+			URL:   "https://github.com/acme/widget/commits/1a2b3c4",
+		})
+	}
+	tmpl, err := parseTemplate("discord", "text", discordTemplate, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := render(chunkRenderer{tmpl, discordMessageLimit}, release)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := strings.Split(string(out), discordDelimiter)
+	if len(messages) < 2 {
+		t.Fatalf("got %d messages, want the release split up", len(messages))
+	}
+	for i, msg := range messages {
+		if n := utf8.RuneCountInString(msg); n > discordMessageLimit {
+			t.Errorf("message %d has %d characters", i, n)
+		}
+	}
+	for _, want := range []string{`\*stars\*`, "(<https://github.com/acme/widget/commits/1a2b3c4>)"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output has no %q", want)
+		}
+	}
+}
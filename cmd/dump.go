@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	dumpCmd.Flags().Bool("json", false, "Print the commits as a JSON array")
+	rootCmd.AddCommand(dumpCmd)
+}
+
+// dumpedCommit is how a commit was parsed and whether it made it into the
+// changelog.
+type dumpedCommit struct {
+	SHA      string `json:"sha"`
+	Title    string `json:"title"`
+	Type     string `json:"type,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	Breaking bool   `json:"breaking"`
+	PR       int    `json:"pr,omitempty"`
+	Skipped  bool   `json:"skipped"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+var dumpCmd = &cobra.Command{
+	Use:    "dump",
+	Short:  "Print how each commit since the last tag is parsed and filtered",
+	Args:   cobra.NoArgs,
+	Hidden: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		gen, head, err := newGenerator(cmd)
+		bail(err)
+
+		commits := []dumpedCommit{}
+		gen.trace = func(change Change, reason string) {
+			commits = append(commits, dumpedCommit{
+				SHA:      change.SHA,
+				Title:    change.Title,
+				Type:     change.Type,
+				Scope:    change.Scope,
+				Breaking: change.Breaking,
+				PR:       change.PR,
+				Skipped:  reason != "",
+				Reason:   reason,
+			})
+		}
+		err = gen.walk(head, "", "", false, func(release *Release) error {
+			return ErrStopIteration
+		})
+		bail(err)
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			bail(enc.Encode(commits))
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "SHA\tTYPE\tSCOPE\tPR\tSTATUS\tSUBJECT")
+		for _, c := range commits {
+			pr, status := "", "kept"
+			if c.PR != 0 {
+				pr = fmt.Sprintf("#%d", c.PR)
+			}
+			if c.Skipped {
+				status = "skipped: " + c.Reason
+			}
+			typ := c.Type
+			if c.Breaking {
+				typ += "!"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", c.SHA, typ, c.Scope, pr, status, c.Title)
+		}
+		bail(w.Flush())
+	},
+}
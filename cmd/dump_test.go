@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: first")
+	r.tag("v1.0.0")
+	r.commit("feat(api)!: drop v1 (#12)")
+	r.commit("docs: typo")
+
+	var got []dumpedCommit
+	out := runSumit(t, r.dir, "dump", "--json", "--include-types", "feat")
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("%v in %s", err, out)
+	}
+	for i := range got {
+		got[i].SHA = ""
+	}
+	want := []dumpedCommit{
+		{Title: "docs: typo", Type: "docs", Skipped: true, Reason: `type "docs" not included`},
+		{Title: "feat(api)!: drop v1 (#12)", Type: "feat", Scope: "api", Breaking: true, PR: 12},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	table := lines(runSumit(t, r.dir, "dump", "--include-types", "feat"))
+	if len(table) != 3 || !strings.HasPrefix(table[0], "SHA ") {
+		t.Fatalf("got %q, want a header and two commits", table)
+	}
+	for i, want := range []string{`skipped: type "docs" not included`, "feat!  api    #12  kept"} {
+		if !strings.Contains(table[i+1], want) {
+			t.Errorf("row %q has no %q", table[i+1], want)
+		}
+	}
+}
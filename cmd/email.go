@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// emailSubject is the "subject" sub-template used unless the template or
+// --email-subject defines one.
+const emailSubject = `Release {{ .Version }}`
+
+// emailTemplate is the plain-links format with a subject for its message.
+const emailTemplate = `{{ define "subject" }}` + emailSubject + `{{ end -}}
+` + plainLinksTemplate
+
+// emailRenderer writes a release as an email message: a Subject header
+// rendered with the "subject" sub-template, followed by the plain-text body
+// rendered with the whole template.
+type emailRenderer struct {
+	renderer
+	tmpl *template.Template
+}
+
+// newEmailRenderer sets up the email format for a parsed template, whose
+// "subject" sub-template is replaced with subject when it's given.
+func newEmailRenderer(tmpl renderer, subject string) (renderer, error) {
+	t, ok := tmpl.(*template.Template)
+	if !ok {
+		return nil, errors.New("the email format needs a text template")
+	}
+	if subject == "" && t.Lookup("subject") == nil {
+		subject = emailSubject
+	}
+	if subject != "" {
+		if _, err := t.New("subject").Parse(subject); err != nil {
+			return nil, errors.Wrap(err, "failed to parse email subject")
+		}
+	}
+	return emailRenderer{tmpl, t}, nil
+}
+
+func (r emailRenderer) Execute(w io.Writer, data any) error {
+	var subject bytes.Buffer
+	if err := r.tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return err
+	}
+	// headers are a line each, and RFC 2047 encoded when they aren't ASCII
+	line := strings.Join(strings.Fields(subject.String()), " ")
+	if _, err := fmt.Fprintf(w, "Subject: %s\nMIME-Version: 1.0\nContent-Type: text/plain; charset=utf-8\n\n", mime.QEncoding.Encode("utf-8", line)); err != nil {
+		return err
+	}
+	return r.renderer.Execute(w, data)
+}
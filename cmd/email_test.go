@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEmail(t *testing.T) {
+	const headers = "\nMIME-Version: 1.0\nContent-Type: text/plain; charset=utf-8"
+	tests := []struct {
+		name     string
+		template string
+		subject  string
+		want     string
+	}{
+		{"default subject", emailTemplate, "", "Subject: Release 1.1.0"},
+		{"custom subject", emailTemplate, "widget {{ .Version }} is out", "Subject: widget 1.1.0 is out"},
+		{"subject on one line", emailTemplate, "widget\n{{ .Version }}  is out\n", "Subject: widget 1.1.0 is out"},
+		{"encoded subject", emailTemplate, "widget {{ .Version }} est prêt", "Subject: =?utf-8?q?widget_1.1.0_est_pr=C3=AAt?="},
+		{"template's own subject", `{{ define "subject" }}New: {{ .Version }}{{ end }}` + plainLinksTemplate, "", "Subject: New: 1.1.0"},
+		{"template without a subject", plainLinksTemplate, "", "Subject: Release 1.1.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parseTemplate("email", "text", tt.template, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			email, err := newEmailRenderer(tmpl, tt.subject)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := email.Execute(&buf, sampleRelease()); err != nil {
+				t.Fatal(err)
+			}
+			header, body, ok := strings.Cut(buf.String(), "\n\n")
+			if !ok {
+				t.Fatalf("no blank line after the headers:\n%s", buf.String())
+			}
+			if want := tt.want + headers; header != want {
+				t.Errorf("got headers\n%s\nwant\n%s", header, want)
+			}
+			if want := renderFormat(t, "plain-links", sampleRelease()); body != want {
+				t.Errorf("got body\n%s\nwant the plain-links format\n%s", body, want)
+			}
+		})
+	}
+}
+
+func TestEmailSetupErrors(t *testing.T) {
+	tests := []struct {
+		name, engine, subject string
+		wantErr               string
+	}{
+		{"bad subject", "text", "{{ .Version ", "failed to parse email subject"},
+		{"html engine", "html", "", "the email format needs a text template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parseTemplate("email", tt.engine, emailTemplate, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := newEmailRenderer(tmpl, tt.subject); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
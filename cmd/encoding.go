@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// commitMessage returns a commit's message as UTF-8, transcoding it from the
+// encoding named in the commit's encoding header, as git does for
+// i18n.commitEncoding. Messages in an unknown encoding, or that don't decode,
+// are kept as they are with invalid bytes replaced.
+func commitMessage(c *object.Commit) string {
+	message := c.Message
+	name := strings.TrimSpace(string(c.Encoding))
+	if name != "" && !strings.EqualFold(name, "UTF-8") {
+		if enc, err := htmlindex.Get(name); err == nil {
+			if decoded, err := enc.NewDecoder().String(message); err == nil {
+				message = decoded
+			}
+		}
+	}
+	return strings.ToValidUTF8(message, "\uFFFD")
+}
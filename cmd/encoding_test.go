@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestCommitMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		encoding object.MessageEncoding
+		want     string
+	}{
+		{"utf-8", "fix: café", "", "fix: café"},
+		{"explicit utf-8", "fix: café", "UTF-8", "fix: café"},
+		{"latin-1", "fix: caf\xe9", "ISO-8859-1", "fix: café"},
+		{"windows-1252", "fix: \x93quoted\x94", "windows-1252", "fix: “quoted”"},
+		{"shift-jis", "fix: \x93\xfa\x96\x7b", "Shift_JIS", "fix: 日本"},
+		{"unknown encoding", "fix: caf\xe9", "x-made-up", "fix: caf�"},
+		{"undeclared latin-1", "fix: caf\xe9", "", "fix: caf�"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &object.Commit{Message: tt.message, Encoding: tt.encoding}
+			if got := commitMessage(c); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
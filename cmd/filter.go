@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// unsignedReason is the skip reason for a change without a sign-off.
+const unsignedReason = "missing Signed-off-by trailer"
+
+// defaultBotPatterns match the names and emails of common CI and dependency
+// update bots, for --exclude-bots. GitHub Apps commit as "name[bot]".
+var defaultBotPatterns = []string{
+	`\[bot\]`,
+	`^dependabot\b`,
+	`^renovate\b`,
+	`^github-actions\b`,
+	`^greenkeeper\b`,
+	`^snyk-bot\b`,
+	`^pre-commit-ci\b`,
+}
+
+// botPattern combines the default bot patterns with extra ones into a
+// single case-insensitive regular expression.
+func botPattern(extra []string) (*regexp.Regexp, error) {
+	for _, pattern := range extra {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, errors.Wrapf(err, "invalid bot-pattern %q", pattern)
+		}
+	}
+	patterns := append(append([]string(nil), defaultBotPatterns...), extra...)
+	return regexp.MustCompile("(?i)(?:" + strings.Join(patterns, ")|(?:") + ")"), nil
+}
+
+// filters decides which commits are left out of the changelog.
+type filters struct {
+	includeTypes   map[string]bool
+	keepUnmatched  bool
+	requireSignoff bool
+	dropMerges     bool
+	prsOnly        bool
+	releaseCommits *regexp.Regexp
+	bots           *regexp.Regexp
+	// notBefore drops commits dated before it, for --max-age.
+	notBefore time.Time
+	// notes holds the dedupe keys of the entries merged in with
+	// --merge-notes, which take the place of the commits they match.
+	notes    map[string]bool
+	dedupeBy string
+	seen     map[string]string
+}
+
+// skipReason explains why a change, made at the given time, should be left
+// out, or returns an empty string when it should be kept. Hand-written
+// entries have no time and are never too old.
+func (f *filters) skipReason(change Change, when time.Time) string {
+	if !change.Fragment && when.Before(f.notBefore) {
+		return "older than max-age"
+	}
+	if len(f.includeTypes) > 0 {
+		if change.Type == "" {
+			if !f.keepUnmatched {
+				return "not a conventional commit"
+			}
+		} else if !f.includeTypes[change.Type] {
+			return fmt.Sprintf("type %q not included", change.Type)
+		}
+	}
+	if f.releaseCommits != nil && f.releaseCommits.MatchString(change.Title) {
+		return "release commit"
+	}
+	if f.bots != nil && (f.bots.MatchString(change.Author) || f.bots.MatchString(change.Email)) {
+		return "bot author"
+	}
+	if f.dropMerges && change.Merge {
+		return "merge commit"
+	}
+	if f.prsOnly && change.PR == 0 && !change.Fragment {
+		return "no pull request"
+	}
+	if f.requireSignoff && !change.SignedOff && !change.Fragment {
+		return unsignedReason
+	}
+	if len(f.notes) > 0 && !change.Fragment {
+		if f.notes[dedupeKey(change, "normalized")] || f.notes[dedupeKey(change, "pr")] {
+			return "listed in merged notes"
+		}
+	}
+	if f.dedupeBy != "" {
+		if key := dedupeKey(change, f.dedupeBy); key != "" {
+			if sha, ok := f.seen[key]; ok {
+				return fmt.Sprintf("duplicate of %s", sha)
+			}
+			if f.seen == nil {
+				f.seen = make(map[string]string)
+			}
+			f.seen[key] = change.SHA
+		}
+	}
+	return ""
+}
+
+// dedupeKey is what two changes must share to be considered duplicates.
+// Changes with an empty key are never deduplicated.
+func dedupeKey(change Change, by string) string {
+	switch by {
+	case "normalized":
+		return strings.ToLower(strings.Join(strings.Fields(change.Title), " "))
+	case "pr":
+		if change.PR == 0 {
+			return ""
+		}
+		return fmt.Sprintf("#%d", change.PR)
+	}
+	return change.Title
+}
+
+// noteKeys collects the keys a commit is matched against the merged notes
+// by: the normalized subject and, when there is one, the pull request.
+func noteKeys(notes []Change) map[string]bool {
+	keys := make(map[string]bool, 2*len(notes))
+	for _, note := range notes {
+		for _, by := range []string{"normalized", "pr"} {
+			if key := dedupeKey(note, by); key != "" {
+				keys[key] = true
+			}
+		}
+	}
+	return keys
+}
+
+func newTypeSet(types []string) map[string]bool {
+	set := make(map[string]bool, len(types))
+	for _, t := range types {
+		set[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	return set
+}
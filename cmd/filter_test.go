@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedupeKey(t *testing.T) {
+	change := Change{Title: "  Fix   the Parser ", PR: 12}
+	tests := []struct {
+		by     string
+		change Change
+		want   string
+	}{
+		{"subject", change, "  Fix   the Parser "},
+		{"normalized", change, "fix the parser"},
+		{"pr", change, "#12"},
+		{"pr", Change{Title: "no pull request"}, ""},
+	}
+	for _, tt := range tests {
+		if got := dedupeKey(tt.change, tt.by); got != tt.want {
+			t.Errorf("dedupeKey(%q, %s) = %q, want %q", tt.change.Title, tt.by, got, tt.want)
+		}
+	}
+}
+
+func TestDedupeBy(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("fix: parser crash (#7)")
+	r.commit("Fix:  parser  crash")
+	r.commit("fix: parser crash")
+	r.commit("fix: parser crash (#7)")
+	r.commit("fix: retry the parser crash fix (#7)")
+
+	tests := []struct {
+		by   string
+		want []string
+	}{
+		{"subject", []string{"fix: retry the parser crash fix (#7)", "fix: parser crash (#7)", "fix: parser crash", "Fix:  parser  crash"}},
+		{"normalized", []string{"fix: retry the parser crash fix (#7)", "fix: parser crash (#7)", "fix: parser crash"}},
+		{"pr", []string{"fix: retry the parser crash fix (#7)", "fix: parser crash", "Fix:  parser  crash"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.by, func(t *testing.T) {
+			out := runSumit(t, r.dir, "--dedupe", "--dedupe-by", tt.by, "1.0.0")
+			if got := changeTitles(out); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedupeByRejectsUnknownKeys(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: first")
+
+	out := runSumitError(t, r.dir, "--dedupe-by", "author", "1.0.0")
+	if !strings.Contains(out, "unsupported dedupe-by value: author") {
+		t.Errorf("got %q, want an unsupported value error", out)
+	}
+}
+
+func TestRequireSignoff(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: signed\n\nSigned-off-by: Jane Doe <jane@example.com>")
+	r.commit("fix: unsigned")
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantLog string
+	}{
+		{"off", nil, []string{"fix: unsigned", "feat: signed"}, ""},
+		{"warn", []string{"--require-signoff"}, []string{"fix: unsigned", "feat: signed"}, "warning: "},
+		{"exclude", []string{"--require-signoff=exclude"}, []string{"feat: signed"}, "dropped "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			var log strings.Builder
+			gen.progress.w = &log
+			if got := titles(walkReleases(t, gen, head)[0].Changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			switch {
+			case tt.wantLog == "" && log.Len() > 0:
+				t.Errorf("got log %q, want none", log.String())
+			case tt.wantLog != "" && !strings.HasPrefix(log.String(), tt.wantLog):
+				t.Errorf("got log %q, want it to start with %q", log.String(), tt.wantLog)
+			case tt.wantLog != "" && !strings.Contains(log.String(), "fix: unsigned: "+unsignedReason):
+				t.Errorf("log %q doesn't name the unsigned commit", log.String())
+			}
+		})
+	}
+}
+
+func TestRequireSignoffRejectsUnknownModes(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: first")
+
+	_, _, err := newTestGenerator(t, r.dir, "--require-signoff=drop")
+	if err == nil || !strings.Contains(err.Error(), "unsupported require-signoff value") {
+		t.Errorf("got %v, want an unsupported value error", err)
+	}
+}
+
+func TestDropMergeSubjects(t *testing.T) {
+	r := newTestRepo(t)
+	base := r.commit("feat: base")
+	topic := r.commit("feat: topic work")
+	r.reset(base)
+	r.commit("fix: mainline work")
+	r.merge(topic, "Merge branch 'topic'")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"merges kept", nil, []string{"Merge branch 'topic'", "fix: mainline work", "feat: base", "feat: topic work"}},
+		{"merge subjects dropped", []string{"--drop-merge-subjects"}, []string{"fix: mainline work", "feat: base", "feat: topic work"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			changes := walkReleases(t, gen, head)[0].Changes
+			if got := titles(changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if tt.args == nil && !changes[0].Merge {
+				t.Error("merge commit isn't marked as a merge")
+			}
+		})
+	}
+}
+
+func TestSkipReleaseCommits(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a")
+	r.commit("chore(release): 1.2.0")
+	r.commit("Bump version to 1.2.0")
+	r.commit("release: v1.2.0")
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{"off", nil, []string{"release: v1.2.0", "Bump version to 1.2.0", "chore(release): 1.2.0", "feat: a"}, false},
+		{"default pattern", []string{"--skip-release-commits"}, []string{"release: v1.2.0", "feat: a"}, false},
+		{"custom pattern", []string{"--skip-release-commits", "--release-commit-pattern", `^release: v\d`}, []string{"Bump version to 1.2.0", "chore(release): 1.2.0", "feat: a"}, false},
+		{"invalid pattern", []string{"--skip-release-commits", "--release-commit-pattern", "("}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head, err := newTestGenerator(t, r.dir, tt.args...)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "invalid release-commit-pattern") {
+					t.Errorf("got %v, want an invalid pattern error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := titles(walkReleases(t, gen, head)[0].Changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBotPattern(t *testing.T) {
+	tests := []struct {
+		author string
+		extra  []string
+		want   bool
+	}{
+		{"dependabot[bot]", nil, true},
+		{"49699333+dependabot[bot]@users.noreply.github.com", nil, true},
+		{"Renovate Bot", nil, true},
+		{"github-actions", nil, true},
+		{"Jane Doe", nil, false},
+		{"Dependabot fan", nil, true},
+		{"a-dependabot-fan", nil, false},
+		{"ci@acme.example", []string{`^ci@acme\.example$`}, true},
+		{"Jane Doe", []string{`^ci@`}, false},
+	}
+	for _, tt := range tests {
+		re, err := botPattern(tt.extra)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := re.MatchString(tt.author); got != tt.want {
+			t.Errorf("bot pattern with %q matches %q: %v, want %v", tt.extra, tt.author, got, tt.want)
+		}
+	}
+
+	if _, err := botPattern([]string{"("}); err == nil || !strings.Contains(err.Error(), "invalid bot-pattern") {
+		t.Errorf("got %v, want an invalid bot-pattern error", err)
+	}
+}
+
+func TestExcludeBots(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: by a person")
+	r.commitBy("dependabot[bot]", "49699333+dependabot[bot]@users.noreply.github.com", "build(deps): bump x")
+	r.commitBy("Release Robot", "ci@acme.example", "chore: release")
+
+	tests := []struct {
+		name   string
+		args   []string
+		config string
+		want   []string
+	}{
+		{"off", nil, "", []string{"chore: release", "build(deps): bump x", "feat: by a person"}},
+		{"default patterns", []string{"--exclude-bots"}, "", []string{"chore: release", "feat: by a person"}},
+		{"extra pattern", []string{"--exclude-bots", "--bot-pattern", `^ci@acme\.example$`}, "", []string{"feat: by a person"}},
+		{"config", nil, "exclude-bots: true\nbot-pattern:\n  - Release Robot\n", []string{"feat: by a person"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.writeFile(configFile, tt.config)
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			if got := titles(walkReleases(t, gen, head)[0].Changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	pinNow(t, time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC))
+	r := newTestRepo(t)
+	r.commit("feat: nine days old")
+	r.when = time.Date(2024, 1, 5, 12, 0, 0, 0, time.UTC)
+	r.commit("fix: five days old")
+	r.when = time.Date(2024, 1, 9, 12, 0, 0, 0, time.UTC)
+	r.commit("feat: a day old")
+	r.writeFile(".changelog.d/entry.md", "fix: hand-written\n")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"off", nil, []string{"fix: hand-written", "feat: a day old", "fix: five days old", "feat: nine days old"}},
+		{"a week", []string{"--max-age", "168h"}, []string{"fix: hand-written", "feat: a day old", "fix: five days old"}},
+		{"two days", []string{"--max-age", "48h"}, []string{"fix: hand-written", "feat: a day old"}},
+		{"with other filters", []string{"--max-age", "168h", "--include-types", "fix"}, []string{"fix: hand-written", "fix: five days old"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			if got := titles(walkReleases(t, gen, head)[0].Changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
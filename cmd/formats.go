@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	rootCmd.AddCommand(formatsCmd)
+}
+
+var formatsCmd = &cobra.Command{
+	Use:   "formats",
+	Short: "List the output formats",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, f := range outputFormats {
+			fmt.Fprintf(w, "%s\t%s\n", f.name, f.description)
+		}
+		bail(w.Flush())
+	},
+}
+
+// outputFormat describes how releases are rendered for one --output-format
+// value.
+type outputFormat struct {
+	name        string
+	description string
+	// template is the built-in release template. Data formats have none.
+	template string
+	// extension is the extension of the files --split-output writes.
+	extension string
+	// data, when set, returns a renderer that encodes releases without a
+	// template, so the format can't be combined with --template or
+	// --commit-template.
+	data func() renderer
+	// continued, when set, returns the renderer for the releases that
+	// follow the first one in the same output, such as a table without
+	// another header row.
+	continued func() renderer
+	// html formats are parsed with html/template for context-aware
+	// escaping.
+	html bool
+	// link, escape and code back the template helpers of the same names.
+	// They default to markdownLink, leaving text as it is and backticks.
+	link   func(text, url string) string
+	escape func(string) string
+	code   func(string) string
+	// line formats write one record per line, so releases rendered one
+	// after the other aren't separated.
+	line bool
+	// separator is written between releases of formats that aren't line
+	// formats, instead of a blank line.
+	separator string
+	// groupByType groups changes by type unless --group-by is given.
+	groupByType bool
+	// toc formats have markdown headings that --toc can link to.
+	toc bool
+	// envelope, when set, encloses each rendered release, such as in an
+	// email message. It applies before --wrap and --normalize-whitespace.
+	envelope func(r renderer, flags *pflag.FlagSet) (renderer, error)
+	// wrap, when set, post-processes whatever the template renders, after
+	// every other option has.
+	wrap func(renderer) renderer
+	// check, when set, is given the changes once they're all written and
+	// fails the run if they aren't acceptable.
+	check func([]Change) error
+}
+
+// outputFormats lists the built-in formats in the order they're documented.
+var outputFormats = []*outputFormat{
+	{
+		name:        "markdown",
+		description: "Markdown with a heading per release, the default",
+		extension:   ".md",
+		template:    releaseTemplate,
+		toc:         true,
+	},
+	{
+		name:        "markdown-refs",
+		description: "Markdown with Keep a Changelog style link references",
+		extension:   ".md",
+		template:    referenceTemplate,
+		toc:         true,
+	},
+	{
+		name:        "markdown-table",
+		description: "Markdown with a table of changes per release",
+		extension:   ".md",
+		template:    markdownTableTemplate,
+		toc:         true,
+	},
+	{
+		name:        "github-release",
+		description: "A \"What's Changed\" body for GitHub releases",
+		extension:   ".md",
+		template:    githubReleaseTemplate,
+		groupByType: true,
+	},
+	{
+		name:        "slack",
+		description: "Slack mrkdwn",
+		extension:   ".txt",
+		template:    slackTemplate,
+		link:        slackLink,
+		escape:      slackEscaper.Replace,
+	},
+	{
+		name:        "discord",
+		description: "Discord markdown, split into messages",
+		extension:   ".md",
+		template:    discordTemplate,
+		link:        discordLink,
+		escape:      discordEscaper.Replace,
+		// every release starts a new message
+		separator: discordDelimiter,
+		wrap: func(r renderer) renderer {
+			return chunkRenderer{r, discordMessageLimit}
+		},
+	},
+	{
+		name:        "html",
+		description: "An HTML section per release",
+		extension:   ".html",
+		template:    htmlTemplate,
+		html:        true,
+	},
+	{
+		name:        "plain-links",
+		description: "Plain text with bare URLs",
+		extension:   ".txt",
+		template:    plainLinksTemplate,
+		link:        plainLink,
+	},
+	{
+		name:        "confluence-wiki",
+		description: "Confluence wiki markup",
+		extension:   ".txt",
+		template:    confluenceTemplate,
+		link:        confluenceLink,
+		escape:      confluenceEscaper.Replace,
+		code:        confluenceCode,
+	},
+	{
+		name:        "email",
+		description: "An email message with a subject",
+		extension:   ".eml",
+		template:    emailTemplate,
+		link:        plainLink,
+		envelope: func(r renderer, flags *pflag.FlagSet) (renderer, error) {
+			subject, _ := flags.GetString("email-subject")
+			return newEmailRenderer(r, subject)
+		},
+	},
+	{
+		name:        "gitlab-release",
+		description: "A line of JSON for GitLab's release-cli",
+		extension:   ".md",
+		template:    githubReleaseTemplate,
+		line:        true,
+		groupByType: true,
+		wrap: func(r renderer) renderer {
+			return gitlabReleaseRenderer{r}
+		},
+	},
+	{
+		name:        "commitlint-check",
+		description: "The commits that aren't conventional commits",
+		extension:   ".txt",
+		template:    commitlintTemplate,
+		line:        true,
+		check:       checkConventional,
+	},
+	{
+		name:        "json",
+		description: "A JSON document per release",
+		extension:   ".json",
+		data:        func() renderer { return jsonRenderer{} },
+	},
+	{
+		name:        "ndjson",
+		description: "A line of JSON per release and per change",
+		extension:   ".ndjson",
+		data:        func() renderer { return ndjsonRenderer{} },
+		line:        true,
+	},
+	{
+		name:        "json-lines-per-release",
+		description: "A line of JSON per release",
+		extension:   ".ndjson",
+		data:        func() renderer { return releaseLineRenderer{} },
+		line:        true,
+	},
+	{
+		name:        "csv",
+		description: "A row per change",
+		extension:   ".csv",
+		data:        func() renderer { return &csvRenderer{header: true} },
+		line:        true,
+		// the releases make up a single table with one header row
+		continued: func() renderer { return &csvRenderer{} },
+	},
+	{
+		name:        "yaml",
+		description: "A YAML document per release",
+		extension:   ".yaml",
+		data:        func() renderer { return yamlRenderer{} },
+		separator:   "---\n",
+	},
+}
+
+// lookupFormat finds the format for an --output-format value, where an
+// empty one means markdown.
+func lookupFormat(name string) (*outputFormat, error) {
+	if name == "" {
+		name = "markdown"
+	}
+	for _, f := range outputFormats {
+		if f.name == name {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, name)
+}
+
+// formatNames lists the names of the built-in formats.
+func formatNames() []string {
+	names := make([]string, len(outputFormats))
+	for i, f := range outputFormats {
+		names[i] = f.name
+	}
+	return names
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatRegistry(t *testing.T) {
+	want := []string{
+		"markdown", "markdown-refs", "markdown-table", "github-release", "slack", "discord",
+		"html", "plain-links", "confluence-wiki", "email", "gitlab-release", "commitlint-check",
+		"json", "ndjson", "json-lines-per-release", "csv", "yaml",
+	}
+	if got := formatNames(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got formats %q, want %q", got, want)
+	}
+
+	for _, f := range outputFormats {
+		t.Run(f.name, func(t *testing.T) {
+			if f.description == "" {
+				t.Error("has no description")
+			}
+			if (f.template == "") == (f.data == nil) {
+				t.Error("needs either a template or a data renderer")
+			}
+			if f.extension == "" {
+				t.Error("has no extension for --split-output")
+			}
+			got, err := lookupFormat(f.name)
+			if err != nil || got != f {
+				t.Errorf("lookupFormat = %v, %v", got, err)
+			}
+
+			var out []byte
+			if f.data != nil {
+				var buf bytes.Buffer
+				if err := f.data().Execute(&buf, sampleRelease()); err != nil {
+					t.Fatal(err)
+				}
+				out = buf.Bytes()
+			} else {
+				out = []byte(renderFormat(t, f.name, sampleRelease()))
+			}
+			if !bytes.Contains(out, []byte("1.1.0")) && !bytes.Contains(out, []byte("reject empty names")) {
+				t.Errorf("rendered neither the version nor a change:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestLookupFormat(t *testing.T) {
+	f, err := lookupFormat("")
+	if err != nil || f.name != "markdown" {
+		t.Errorf("got %v, %v for no format, want markdown", f, err)
+	}
+	if _, err := lookupFormat("plist"); !errors.Is(err, ErrUnsupportedFormat) || !strings.Contains(err.Error(), "plist") {
+		t.Errorf("got %v, want ErrUnsupportedFormat for plist", err)
+	}
+}
+
+func TestFormatsCommand(t *testing.T) {
+	out := runSumit(t, t.TempDir(), "formats")
+	got := lines(out)
+	if len(got) != len(outputFormats) {
+		t.Fatalf("got %d lines, want one per format:\n%s", len(got), out)
+	}
+	for i, f := range outputFormats {
+		if name, description, _ := strings.Cut(got[i], " "); name != f.name || strings.TrimSpace(description) != f.description {
+			t.Errorf("line %d is %q, want %s and its description", i+1, got[i], f.name)
+		}
+	}
+	usage := rootCmd.PersistentFlags().Lookup("output-format").Usage
+	for _, name := range formatNames() {
+		if !strings.Contains(usage, name) {
+			t.Errorf("--output-format help doesn't list %s", name)
+		}
+	}
+}
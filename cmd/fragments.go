@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// readFragments loads the hand-written entries in dir, one per file, in
+// file name order. Hidden files and subdirectories are ignored, and a
+// missing directory holds no fragments.
+//
+// A fragment's first line is its title and the rest, after a blank line, its
+// body. It can start with "Type:" and "Scope:" header lines to categorize
+// it; otherwise a conventional commit title is parsed like a commit's.
+func readFragments(dir string) ([]Change, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", dir)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	var changes []Change
+	for _, name := range names {
+		text, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read fragment %s", name)
+		}
+		if change, ok := parseFragment(string(text)); ok {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// parseFragment turns the text of a fragment file into a change. The second
+// return value is false for a fragment without a title.
+func parseFragment(text string) (Change, bool) {
+	change := Change{Fragment: true}
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n")), "\n")
+headers:
+	for len(lines) > 0 {
+		key, value, _ := strings.Cut(lines[0], ":")
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "type":
+			change.Type = strings.ToLower(strings.TrimSpace(value))
+		case "scope":
+			change.Scope = strings.TrimSpace(value)
+		default:
+			break headers
+		}
+		lines = lines[1:]
+	}
+
+	message := strings.TrimSpace(strings.Join(lines, "\n"))
+	if message == "" {
+		return Change{}, false
+	}
+	title, body, _ := strings.Cut(message, "\n")
+	change.Title = strings.TrimSpace(title)
+	change.Body = strings.TrimSpace(body)
+	if change.Type == "" {
+		if cc, ok := parseConventional(change.Title); ok {
+			change.Type = cc.Type
+			change.Scope = cc.Scope
+			change.Breaking = cc.Breaking
+			change.Conventional = true
+		}
+	}
+	return change, true
+}
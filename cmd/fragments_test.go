@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFragment(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want Change
+		ok   bool
+	}{
+		{"plain title", "Reworded the install guide\n", Change{Title: "Reworded the install guide", Fragment: true}, true},
+		{"conventional title", "feat(cli)!: new flags", Change{Title: "feat(cli)!: new flags", Type: "feat", Scope: "cli", Breaking: true, Conventional: true, Fragment: true}, true},
+		{"headers", "Type: Fix\nScope: api\nHandle empty pages\n\nThey used to crash.", Change{Title: "Handle empty pages", Type: "fix", Scope: "api", Body: "They used to crash.", Fragment: true}, true},
+		{"header wins over title", "type: docs\nfeat: looks like a feature", Change{Title: "feat: looks like a feature", Type: "docs", Fragment: true}, true},
+		{"windows line endings", "Type: perf\r\nFaster startup\r\n", Change{Title: "Faster startup", Type: "perf", Fragment: true}, true},
+		{"headers only", "Type: feat\n", Change{}, false},
+		{"empty", "\n\n", Change{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseFragment(tt.text)
+			if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, %v, want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestFragments(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a")
+	r.tag("v1.0.0")
+	r.commit("fix: b")
+	r.writeFile(".changelog.d/02-docs.md", "Type: docs\nRewrote the guide\n")
+	r.writeFile(".changelog.d/01-feat.md", "feat: hand-written feature\n")
+	r.writeFile(".changelog.d/.hidden", "Not an entry\n")
+	r.writeFile(".changelog.d/nested/entry.md", "Not an entry either\n")
+
+	tests := []struct {
+		name   string
+		args   []string
+		want   []string
+		tagged []string
+	}{
+		{"default dir", nil, []string{"feat: hand-written feature", "Rewrote the guide", "fix: b"}, []string{"feat: a"}},
+		{"missing dir", []string{"--fragments-dir", "news"}, []string{"fix: b"}, []string{"feat: a"}},
+		{"filtered by type", []string{"--include-types", "feat,fix"}, []string{"feat: hand-written feature", "fix: b"}, []string{"feat: a"}},
+		{"exempt from sign-off", []string{"--require-signoff=exclude"}, []string{"feat: hand-written feature", "Rewrote the guide"}, []string{}},
+		{"disabled", []string{"--fragments-dir", ""}, []string{"fix: b"}, []string{"feat: a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			releases := walkReleases(t, gen, head)
+			if got := titles(releases[0].Changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			// only the newest release gets the fragments
+			if got := titles(releases[1].Changes); !reflect.DeepEqual(got, tt.tagged) {
+				t.Errorf("got %q in the tagged release", got)
+			}
+		})
+	}
+}
+
+func TestFragmentsOnlyUnreleased(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a")
+	r.tag("v1.0.0")
+	r.writeFile(".changelog.d/entry.md", "Rewrote the guide\n")
+
+	// HEAD is released, so the entry is left for the next release
+	gen, head := testGenerator(t, r.dir)
+	releases := walkReleases(t, gen, head)
+	if got := titles(releases[0].Changes); !reflect.DeepEqual(got, []string{"feat: a"}) {
+		t.Errorf("got %q in the tagged release", got)
+	}
+
+	// an entry alone isn't a change worth releasing
+	gen, head = testGenerator(t, r.dir)
+	if found, err := hasChanges(gen, head); err != nil || found {
+		t.Errorf("got %v, %v from hasChanges with only an entry", found, err)
+	}
+	r.commit("fix: b")
+	gen, head = testGenerator(t, r.dir)
+	if found, err := hasChanges(gen, head); err != nil || !found {
+		t.Errorf("got %v, %v from hasChanges with a new commit", found, err)
+	}
+
+	// nor does an explicit range ending before HEAD get it
+	gen, head = testGenerator(t, r.dir, "--to", "v1.0.0")
+	if got := titles(walkReleases(t, gen, head)[0].Changes); !reflect.DeepEqual(got, []string{"feat: a"}) {
+		t.Errorf("got %q up to the tag", got)
+	}
+}
+
+func TestFragmentsCommitlintCheck(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: conforming")
+	r.writeFile(".changelog.d/entry.md", "Rewrote the guide\n")
+
+	out := runSumit(t, r.dir, "--output-format", "commitlint-check", "1.0.0")
+	if strings.TrimSpace(out) != "" {
+		t.Errorf("got %q, want the entry left out of the check", out)
+	}
+}
+
+func TestFragmentsOtherRef(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a")
+	r.commit("fix: b")
+	r.writeFile(".changelog.d/entry.md", "Rewrote the guide\n")
+
+	tests := []struct {
+		ref  string
+		want []string
+	}{
+		{"HEAD", []string{"Rewrote the guide", "fix: b", "feat: a"}},
+		{"HEAD~1", []string{"feat: a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, "--ref", tt.ref)
+			if got := titles(walkReleases(t, gen, head)[0].Changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
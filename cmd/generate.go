@@ -0,0 +1,721 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// now is the clock that dates unreleased changes and --max-age and
+// --relative-dates are measured against, so it can be pinned.
+var now = sourceDateEpoch
+
+// sourceDateEpoch is the time in SOURCE_DATE_EPOCH, the reproducible builds
+// convention for pinning the build time in seconds since the Unix epoch, or
+// the current time when it isn't set.
+func sourceDateEpoch() time.Time {
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC()
+	}
+	return time.Now()
+}
+
+// generator walks the commit history and turns it into releases.
+type generator struct {
+	repo            *git.Repository
+	remoteURL       string
+	taggedCommits   map[string][]string
+	abbrev          *abbreviator
+	filter          *filters
+	categoryTrailer string
+	withBody        bool
+	maxBodyBytes    int
+	bodyTypes       map[string]bool
+	commentChar     string
+	jiraBase        string
+	gitmoji         bool
+	untypedScopes   bool
+	tagPrefix       string
+	stripEmoji      bool
+	stripPrefixes   []*regexp.Regexp
+	smartSubject    bool
+	groupBy         []string
+	typeTitles      map[string]string
+	emptySections   []string
+	progress        *progress
+	strict          bool
+	signoff         string
+	// noVersionBrackets is copied onto every release for the templates.
+	noVersionBrackets bool
+	domainSummary     bool
+	scopeBadges       bool
+	shaFirst          bool
+	prioritySort      bool
+	collapsePRs       bool
+	hashInTitle       bool
+	showStats         bool
+	goAPIOnly         bool
+	containing        containingRefs
+	submodules        map[string]*submodule
+	relativeDates     bool
+	dateSource        string
+	context           map[string]string
+	// fragments are the hand-written entries added to the release for the
+	// untagged commits.
+	fragments []Change
+	// trace, when set, is called with every commit in the walk and the
+	// reason it was filtered out, if it was.
+	trace func(change Change, reason string)
+	// stopAt holds commits whose history is left out of the walk, such as
+	// the merge base with the branch being compared against.
+	stopAt map[plumbing.Hash]bool
+	// from and to are the refs given with --from and --to, which take the
+	// place of the detected tags at either end of the compare link.
+	from string
+	to   string
+	// ref is the ref given with --ref, which stands in for HEAD.
+	ref string
+	// compareFrom is where the first release's compare link starts, with
+	// --compare-from-initial.
+	compareFrom string
+}
+
+// newGenerator opens the repository in --dir and sets up a generator from
+// the command's flags. It returns the hash the history is walked from.
+func newGenerator(cmd *cobra.Command) (*generator, plumbing.Hash, error) {
+	dir, _ := cmd.Flags().GetString("dir")
+	if dir == "" {
+		dir = "."
+	}
+
+	repo, err := git.PlainOpen(dir)
+	if err == git.ErrRepositoryNotExists {
+		return nil, plumbing.ZeroHash, fmt.Errorf("%w: %s; run sumit from inside a repo or pass --dir", ErrNotARepo, dir)
+	} else if err != nil {
+		return nil, plumbing.ZeroHash, errors.Wrap(err, "failed to open git repository")
+	}
+
+	start, _ := cmd.Flags().GetString("ref")
+	ref, err := startRef(repo, start)
+	if err != nil {
+		return nil, plumbing.ZeroHash, err
+	}
+
+	remoteName := linkRemote(repo, ref)
+	if priority, _ := cmd.Flags().GetStringSlice("remote-priority"); len(priority) > 0 {
+		remoteName = preferredRemote(repo, ref, priority)
+	}
+	remoteURL, _ := cmd.Flags().GetString("base-url")
+	// problems with the remote only cost the links, so they're reported
+	// once the generator can warn about them
+	var remoteErr error
+	if remoteURL != "" {
+		// links go to the public site rather than wherever the remote is
+		if !validBaseURL(remoteURL) {
+			return nil, plumbing.ZeroHash, errors.New(fmt.Sprintf("invalid base-url: %s", remoteURL))
+		}
+		remoteURL = strings.TrimSuffix(remoteURL, "/")
+	} else if remoteName != "" {
+		rem, err := repo.Remote(remoteName)
+		if err != nil {
+			return nil, plumbing.ZeroHash, errors.Wrap(err, fmt.Sprintf("failed to read remote %s", remoteName))
+		}
+		url := rem.Config().URLs[0]
+		remoteURL, remoteErr = parseRemoteURL(url)
+	} else {
+		remoteErr = errors.New("no git remote")
+	}
+	if remoteName == "" {
+		remoteName = "origin"
+	}
+
+	if fetch, _ := cmd.Flags().GetBool("fetch-tags"); fetch {
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		retries, _ := cmd.Flags().GetInt("retries")
+		if err := fetchTags(repo, remoteName, timeout, retries); err != nil {
+			return nil, plumbing.ZeroHash, err
+		}
+	}
+
+	taggedCommits, err := getTaggedCommits(repo)
+	if err != nil {
+		return nil, plumbing.ZeroHash, err
+	}
+	tagPrefix, _ := cmd.Flags().GetString("tag-prefix")
+	if includeAll, _ := cmd.Flags().GetBool("include-nonsemver-tags"); !includeAll {
+		taggedCommits = semverTags(taggedCommits, tagPrefix)
+	}
+
+	abbrevLen, _ := cmd.Flags().GetInt("abbrev")
+	abbrevMinimal, _ := cmd.Flags().GetBool("abbrev-minimal")
+	abbrev, err := newAbbreviator(repo, abbrevLen, abbrevMinimal)
+	if err != nil {
+		return nil, plumbing.ZeroHash, err
+	}
+
+	includeTypes, _ := cmd.Flags().GetStringSlice("include-types")
+	keepUnmatched, _ := cmd.Flags().GetBool("keep-unmatched")
+	filter := &filters{
+		includeTypes:  newTypeSet(includeTypes),
+		keepUnmatched: keepUnmatched,
+	}
+	filter.dropMerges, _ = cmd.Flags().GetBool("drop-merge-subjects")
+	filter.prsOnly, _ = cmd.Flags().GetBool("prs-only")
+	if maxAge, _ := cmd.Flags().GetDuration("max-age"); maxAge > 0 {
+		filter.notBefore = now().Add(-maxAge)
+	}
+	if excludeBots, _ := cmd.Flags().GetBool("exclude-bots"); excludeBots {
+		extra, _ := cmd.Flags().GetStringArray("bot-pattern")
+		filter.bots, err = botPattern(extra)
+		if err != nil {
+			return nil, plumbing.ZeroHash, err
+		}
+	}
+	if skip, _ := cmd.Flags().GetBool("skip-release-commits"); skip {
+		pattern, _ := cmd.Flags().GetString("release-commit-pattern")
+		filter.releaseCommits, err = regexp.Compile(pattern)
+		if err != nil {
+			return nil, plumbing.ZeroHash, errors.Wrap(err, "invalid release-commit-pattern")
+		}
+	}
+	dedupe, _ := cmd.Flags().GetBool("dedupe")
+	if dedupe || flagChanged(cmd, "dedupe-by") {
+		filter.dedupeBy, _ = cmd.Flags().GetString("dedupe-by")
+		switch filter.dedupeBy {
+		case "subject", "normalized", "pr":
+		default:
+			return nil, plumbing.ZeroHash, errors.New(fmt.Sprintf("unsupported dedupe-by value: %s", filter.dedupeBy))
+		}
+	}
+
+	signoff, _ := cmd.Flags().GetString("require-signoff")
+	switch signoff {
+	case "", "warn":
+	case "exclude":
+		filter.requireSignoff = true
+	default:
+		return nil, plumbing.ZeroHash, errors.New(fmt.Sprintf("unsupported require-signoff value: %s", signoff))
+	}
+
+	categoryTrailer, _ := cmd.Flags().GetString("category-trailer")
+	withBody, _ := cmd.Flags().GetBool("with-body")
+	maxBodyBytes, _ := cmd.Flags().GetInt("max-body-bytes")
+	bodyTypes, _ := cmd.Flags().GetStringSlice("include-body-for-types")
+	jiraBase, _ := cmd.Flags().GetString("jira-base")
+	gitmoji, _ := cmd.Flags().GetBool("gitmoji")
+	untypedScopes, _ := cmd.Flags().GetBool("untyped-scopes")
+	stripEmoji, _ := cmd.Flags().GetBool("strip-emoji")
+	prefixValues, _ := cmd.Flags().GetStringArray("strip-prefix")
+	stripPrefixes, err := parseStripPrefixes(prefixValues)
+	if err != nil {
+		return nil, plumbing.ZeroHash, err
+	}
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	strict, _ := cmd.Flags().GetBool("strict")
+	noVersionBrackets, _ := cmd.Flags().GetBool("no-version-brackets")
+	domainSummary, _ := cmd.Flags().GetBool("domain-summary")
+	scopeBadges, _ := cmd.Flags().GetBool("scope-badges")
+	shaPosition, _ := cmd.Flags().GetString("sha-position")
+	prioritySort, _ := cmd.Flags().GetBool("priority-sort")
+	collapsePRs, _ := cmd.Flags().GetBool("collapse-prs")
+	hashInTitle, _ := cmd.Flags().GetBool("include-hash-in-title")
+	showStats, _ := cmd.Flags().GetBool("show-stats")
+	goAPIOnly, _ := cmd.Flags().GetBool("go-api-only")
+	smartSubject, _ := cmd.Flags().GetBool("smart-subject")
+	var submodules map[string]*submodule
+	if include, _ := cmd.Flags().GetBool("include-submodules"); include {
+		submodules, err = openSubmodules(repo)
+		if err != nil {
+			return nil, plumbing.ZeroHash, err
+		}
+	}
+	var containing containingRefs
+	if showBranch, _ := cmd.Flags().GetBool("show-branch"); showBranch {
+		containing, err = newContainingRefs(repo, taggedCommits)
+		if err != nil {
+			return nil, plumbing.ZeroHash, err
+		}
+	}
+	relativeDates, _ := cmd.Flags().GetBool("relative-dates")
+	dateSource, _ := cmd.Flags().GetString("date-source")
+	fragmentsDir, _ := cmd.Flags().GetString("fragments-dir")
+	var fragments []Change
+	if fragmentsDir != "" {
+		fragments, err = readFragments(filepath.Join(dir, fragmentsDir))
+		if err != nil {
+			return nil, plumbing.ZeroHash, err
+		}
+	}
+	if notesPath, _ := cmd.Flags().GetString("merge-notes"); notesPath != "" {
+		notes, err := readNotes(notesPath)
+		if err != nil {
+			return nil, plumbing.ZeroHash, err
+		}
+		filter.notes = noteKeys(notes)
+		fragments = append(fragments, notes...)
+	}
+	for i := range fragments {
+		if !withBody {
+			fragments[i].Body = ""
+		}
+		fragments[i].ScopeBadge = scopeBadges && fragments[i].Scope != ""
+	}
+	var emptySections []string
+	if keepEmpty, _ := cmd.Flags().GetBool("keep-empty-sections"); keepEmpty {
+		// the types that can make it into the changelog
+		emptySections = typeOrder
+		if len(includeTypes) > 0 {
+			// the normalized types the filter matches, in no particular
+			// order since groups are sorted anyway
+			emptySections = nil
+			for t := range filter.includeTypes {
+				if t != "" {
+					emptySections = append(emptySections, t)
+				}
+			}
+		}
+	}
+	titleValues, _ := cmd.Flags().GetStringArray("type-title")
+	typeTitles, err := parseTypeTitles(titleValues)
+	if err != nil {
+		return nil, plumbing.ZeroHash, err
+	}
+	contextValues, _ := cmd.Flags().GetStringArray("template-context")
+	context, err := parseTemplateContext(contextValues)
+	if err != nil {
+		return nil, plumbing.ZeroHash, err
+	}
+	if dateSource != "author" && dateSource != "committer" {
+		return nil, plumbing.ZeroHash, errors.New(fmt.Sprintf("unsupported date-source value: %s", dateSource))
+	}
+	if shaPosition != "before" && shaPosition != "after" {
+		return nil, plumbing.ZeroHash, errors.New(fmt.Sprintf("unsupported sha-position value: %s", shaPosition))
+	}
+
+	gen := &generator{
+		repo:              repo,
+		remoteURL:         remoteURL,
+		taggedCommits:     taggedCommits,
+		abbrev:            abbrev,
+		filter:            filter,
+		categoryTrailer:   categoryTrailer,
+		withBody:          withBody,
+		maxBodyBytes:      maxBodyBytes,
+		bodyTypes:         newTypeSet(bodyTypes),
+		commentChar:       commentChar(repo),
+		jiraBase:          jiraBase,
+		gitmoji:           gitmoji,
+		untypedScopes:     untypedScopes,
+		tagPrefix:         tagPrefix,
+		stripEmoji:        stripEmoji,
+		stripPrefixes:     stripPrefixes,
+		smartSubject:      smartSubject,
+		progress:          newProgress(quiet),
+		strict:            strict,
+		signoff:           signoff,
+		noVersionBrackets: noVersionBrackets,
+		domainSummary:     domainSummary,
+		scopeBadges:       scopeBadges,
+		shaFirst:          shaPosition == "before",
+		prioritySort:      prioritySort,
+		collapsePRs:       collapsePRs,
+		hashInTitle:       hashInTitle,
+		showStats:         showStats,
+		goAPIOnly:         goAPIOnly,
+		containing:        containing,
+		submodules:        submodules,
+		relativeDates:     relativeDates,
+		dateSource:        dateSource,
+		context:           context,
+		typeTitles:        typeTitles,
+		emptySections:     emptySections,
+		fragments:         fragments,
+	}
+
+	if remoteErr != nil {
+		if err := gen.warn("can't link changes: %s", remoteErr); err != nil {
+			return nil, plumbing.ZeroHash, err
+		}
+	}
+	for _, path := range missingSubmodules(submodules) {
+		if err := gen.warn("can't list changes in submodule %s: it isn't checked out", path); err != nil {
+			return nil, plumbing.ZeroHash, err
+		}
+	}
+
+	head := ref.Hash()
+	gen.ref = start
+	gen.from, _ = cmd.Flags().GetString("from")
+	gen.to, _ = cmd.Flags().GetString("to")
+	if gen.to != "" {
+		hash, err := repo.ResolveRevision(plumbing.Revision(gen.to))
+		if err != nil {
+			return nil, plumbing.ZeroHash, &RefError{Ref: gen.to, Err: err}
+		}
+		head = *hash
+	}
+	// the hand-written entries in the work tree describe what's checked
+	// out, not another branch or an older commit
+	if current, err := repo.Head(); err != nil || current.Hash() != head {
+		gen.fragments = nil
+	}
+	gen.stopAt = make(map[plumbing.Hash]bool)
+	if gen.from != "" {
+		gen.stopAt, err = ancestors(repo, gen.from)
+		if err != nil {
+			return nil, plumbing.ZeroHash, err
+		}
+	}
+
+	branchOnly, _ := cmd.Flags().GetBool("branch-only")
+	if branchOnly {
+		base, _ := cmd.Flags().GetString("base")
+		if base == "" {
+			base, err = defaultBranch(repo)
+			if err != nil {
+				return nil, plumbing.ZeroHash, err
+			}
+		}
+		bases, err := mergeBases(repo, head, base)
+		if err != nil {
+			return nil, plumbing.ZeroHash, err
+		}
+		for _, h := range bases {
+			gen.stopAt[h] = true
+		}
+	}
+
+	if initial, _ := cmd.Flags().GetString("compare-from-initial"); initial == initialCommitRef {
+		root, err := initialCommit(repo, head)
+		if err != nil {
+			return nil, plumbing.ZeroHash, err
+		}
+		gen.compareFrom = root.String()
+	} else if initial != "" {
+		if _, err := repo.ResolveRevision(plumbing.Revision(initial)); err != nil {
+			return nil, plumbing.ZeroHash, &RefError{Ref: initial, Err: err}
+		}
+		gen.compareFrom = initial
+	}
+	return gen, head, nil
+}
+
+// walk splits the history reachable from `from` into releases at every
+// tagged commit, calling emit with each release as soon as it's complete,
+// newest first, so callers can stream them out. Commits before the first tag
+// belong to a release named version, for the given tag, and dated today. A
+// tagged starting commit also belongs to it unless labelTags is set, in
+// which case that release is named after the tag instead. emit can return
+// ErrStopIteration to end the walk early.
+func (g *generator) walk(from plumbing.Hash, version, tag string, labelTags bool, emit func(*Release) error) error {
+	head, err := g.repo.CommitObject(from)
+	if err != nil {
+		return errors.Wrap(err, "failed to get commit log")
+	}
+	iter := object.NewCommitPreorderIter(head, g.stopAt, nil)
+	defer g.progress.done()
+
+	release := &Release{Version: version, Tag: tag, Date: now().Format("2006-01-02")}
+	if version == "" {
+		release.Version = "Unreleased"
+	}
+	// fragments only belong to the changes that haven't been released: a
+	// tagged starting commit replaces this release below when labelTags is
+	// set, and drops them with it
+	for _, change := range g.fragments {
+		reason := g.filter.skipReason(change, time.Time{})
+		if g.trace != nil {
+			g.trace(change, reason)
+		}
+		if reason == "" {
+			release.Changes = append(release.Changes, change)
+		}
+	}
+	top := release
+	finish := func(release *Release) *Release {
+		if release == top {
+			return g.finishHead(release)
+		}
+		return g.finish(release)
+	}
+	commits := 0
+
+	err = iter.ForEach(func(c *object.Commit) error {
+		g.progress.tick()
+		if tags, ok := g.taggedCommits[c.Hash.String()]; ok {
+			switch {
+			case commits > 0:
+				release.PreviousTag = tags[0]
+				if err := emit(finish(release)); err != nil {
+					return err
+				}
+				release = g.taggedRelease(tags[0], c)
+				commits = 0
+			case labelTags:
+				release = g.taggedRelease(tags[0], c)
+				top = release
+			}
+		}
+		commits++
+
+		change := g.newChange(c)
+		reason := g.filter.skipReason(change, g.commitDate(c))
+		if reason == "" && g.goAPIOnly && !changesGoAPI(c) {
+			// checked last, since it has to diff the commit
+			reason = goAPIReason
+		}
+		if g.trace != nil {
+			g.trace(change, reason)
+		}
+		if reason != "" {
+			if reason == unsignedReason {
+				g.progress.printf("dropped %s %s: %s\n", change.SHA, change.Title, reason)
+			}
+			return nil
+		}
+		if g.signoff == "warn" && !change.SignedOff {
+			if err := g.warn("%s %s: %s", change.SHA, change.Title, unsignedReason); err != nil {
+				return err
+			}
+		}
+		if strings.TrimSpace(change.Title) == "" {
+			if err := g.warn("%s has an empty subject", change.SHA); err != nil {
+				return err
+			}
+		}
+		if g.hashInTitle {
+			// added after filtering so it doesn't get in the way of
+			// matching subjects
+			change.Title += " (" + change.SHA + ")"
+		}
+		release.Changes = append(release.Changes, change)
+		return nil
+	})
+	if err == nil && commits > 0 {
+		err = emit(finish(release))
+	}
+	if err == ErrStopIteration {
+		return nil
+	}
+	return err
+}
+
+// diffStats sums up a commit's diff against its first parent, or returns
+// nil when it can't be computed.
+func diffStats(c *object.Commit) *DiffStats {
+	files, err := c.Stats()
+	if err != nil {
+		return nil
+	}
+	stats := &DiffStats{Files: len(files)}
+	for _, f := range files {
+		stats.Insertions += f.Addition
+		stats.Deletions += f.Deletion
+	}
+	return stats
+}
+
+// warn reports a problem that doesn't keep the changelog from being
+// generated. With --strict, it's returned as an error instead.
+func (g *generator) warn(format string, args ...any) error {
+	msg := fmt.Sprintf(format, args...)
+	if g.strict {
+		return errors.New(msg)
+	}
+	g.progress.printf("warning: %s\n", msg)
+	return nil
+}
+
+// taggedRelease starts the release for a tagged commit, displaying the tag
+// without its prefix as the version.
+func (g *generator) taggedRelease(tag string, c *object.Commit) *Release {
+	release := &Release{
+		Version: strings.TrimPrefix(tag, g.tagPrefix),
+		Tag:     tag,
+		Date:    g.commitDate(c).Format("2006-01-02"),
+	}
+	// lightweight tags point straight at the commit and have no tagger
+	if ref, err := g.repo.Tag(tag); err == nil {
+		if obj, err := g.repo.TagObject(ref.Hash()); err == nil {
+			release.Tagger = obj.Tagger.Name
+			release.TaggerDate = obj.Tagger.When.Format("2006-01-02")
+		}
+	}
+	return release
+}
+
+// commitDate is the date of a commit according to --date-source: when it
+// was authored, or when it was last committed, which is later for commits
+// that were rebased or cherry-picked.
+func (g *generator) commitDate(c *object.Commit) time.Time {
+	if g.dateSource == "committer" {
+		return c.Committer.When
+	}
+	return c.Author.When
+}
+
+// finish fills in the parts of a release that depend on all of its changes.
+// The oldest release starts at --from, when it's given, rather than at a
+// detected tag.
+func (g *generator) finish(release *Release) *Release {
+	ref := release.Tag
+	if ref == "" {
+		ref = "HEAD"
+		if g.ref != "" {
+			ref = g.ref
+		}
+	}
+	if release.PreviousTag == "" {
+		release.PreviousTag = g.from
+	}
+	release.CompareURL = compareURL(g.remoteURL, release.PreviousTag, ref)
+	if release.PreviousTag == "" {
+		// the first release, which has nothing to compare with unless
+		// --compare-from-initial gives it a baseline
+		release.CompareURL = compareURL(g.remoteURL, g.compareFrom, ref)
+	}
+	release.NoVersionBrackets = g.noVersionBrackets
+	release.Context = g.context
+
+	if g.collapsePRs {
+		release.Changes = collapsePRs(release.Changes, g.withBody)
+	}
+	if g.prioritySort {
+		release.Changes = sortByPriority(release.Changes)
+	}
+	if g.relativeDates {
+		today := now()
+		release.Date = relativeDate(release.Date, today)
+		for i := range release.Changes {
+			release.Changes[i].Date = relativeDate(release.Changes[i].Date, today)
+		}
+	}
+	g.group(release)
+	return release
+}
+
+// group sorts a release's changes into its groups and counts their
+// domains, for the grouping and summary flags.
+func (g *generator) group(release *Release) {
+	release.Groups = groupChanges(release.Changes, g.groupBy, g.typeTitles, g.emptySections)
+	if g.domainSummary {
+		release.Domains = countDomains(release.Changes)
+	}
+}
+
+// dedupeAcrossReleases drops the changes of a commit from every release but
+// the oldest one that lists it, for --dedupe-across-releases. The releases
+// are newest first, as the walk yields them. Hand-written entries have no
+// commit, so they're all kept.
+func (g *generator) dedupeAcrossReleases(releases []*Release) {
+	seen := make(map[string]bool)
+	for i := len(releases) - 1; i >= 0; i-- {
+		release := releases[i]
+		kept := make([]Change, 0, len(release.Changes))
+		for _, change := range release.Changes {
+			if change.SHA != "" {
+				if seen[change.SHA] {
+					continue
+				}
+				seen[change.SHA] = true
+			}
+			kept = append(kept, change)
+		}
+		if len(kept) < len(release.Changes) {
+			release.Changes = kept
+			g.group(release)
+		}
+	}
+}
+
+// finishHead finishes the release the walk starts with, which ends at --to
+// when it's given, whatever the release is going to be tagged as.
+func (g *generator) finishHead(release *Release) *Release {
+	g.finish(release)
+	if g.to != "" {
+		release.CompareURL = compareURL(g.remoteURL, release.PreviousTag, g.to)
+	}
+	return release
+}
+
+func (g *generator) newChange(c *object.Commit) Change {
+	hashStr := c.Hash.String()
+	message := commitMessage(c)
+	if g.smartSubject {
+		message = unwrapSubject(message)
+	}
+	change := Change{
+		SHA:    g.abbrev.abbrev(hashStr),
+		Title:  strings.Split(message, "\n")[0],
+		URL:    commitURL(g.remoteURL, hashStr),
+		Author: c.Author.Name,
+		Email:  c.Author.Email,
+		Date:   g.commitDate(c).Format("2006-01-02"),
+	}
+	change.Merge = c.NumParents() > 1
+	change.SignedOff = hasSignoff(message)
+	change.Trailers = parseTrailers(message)
+	change.PR = prNumber(change.Title)
+	if squash, ok := parseSquashMerge(message); ok {
+		change.Title = squash.Title
+		change.PR = squash.PR
+		if g.withBody {
+			change.Commits = squash.Commits
+		}
+	} else if g.withBody {
+		change.Body = capBody(commitBody(message, g.commentChar), g.maxBodyBytes)
+	}
+	// stripped before parsing, so "[core] feat: x" is a conventional commit
+	change.Title = stripPrefixes(change.Title, g.stripPrefixes)
+	if cc, ok := parseConventional(change.Title); ok {
+		change.Conventional = true
+		change.Type = cc.Type
+		change.Scope = cc.Scope
+		change.Breaking = cc.Breaking
+	} else if cc, ok := parseUntypedScope(change.Title); ok && g.untypedScopes {
+		change.Scope = cc.Scope
+		change.Breaking = cc.Breaking
+	} else if g.gitmoji {
+		change.Type, change.Breaking, _ = parseGitmoji(change.Title)
+	}
+	if hasBreakingFooter(message) {
+		change.Breaking = true
+	}
+	if g.categoryTrailer != "" {
+		if category, ok := trailerValue(change.Trailers, g.categoryTrailer); ok && category != "" {
+			change.Type = strings.ToLower(category)
+		}
+	}
+	if len(g.bodyTypes) > 0 && !g.bodyTypes[change.Type] && !(change.Breaking && g.bodyTypes["breaking"]) {
+		change.Body = ""
+		change.Commits = nil
+	}
+	change.ScopeBadge = g.scopeBadges && change.Scope != ""
+	change.SHAFirst = g.shaFirst
+	if g.showStats {
+		change.Stats = diffStats(c)
+	}
+	if g.containing != nil {
+		change.Branch = g.containing.lookup(c.Hash)
+	}
+	if g.submodules != nil {
+		change.Submodules = g.submoduleChanges(c)
+	}
+	if g.stripEmoji {
+		change.Title = stripLeadingEmoji(change.Title)
+	}
+	if g.jiraBase != "" {
+		change.Issues = findIssues(change.Title, g.jiraBase)
+	}
+	return change
+}
@@ -0,0 +1,476 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// manyTagRepo builds an in-memory repository with a release tag every
+// commitsPerTag commits.
+func manyTagRepo(b *testing.B, tags, commitsPerTag int) (*git.Repository, plumbing.Hash) {
+	b.Helper()
+	storer := memory.NewStorage()
+	repo, err := git.Init(storer, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	store := func(o interface {
+		Encode(plumbing.EncodedObject) error
+	}) plumbing.Hash {
+		obj := storer.NewEncodedObject()
+		if err := o.Encode(obj); err != nil {
+			b.Fatal(err)
+		}
+		hash, err := storer.SetEncodedObject(obj)
+		if err != nil {
+			b.Fatal(err)
+		}
+		return hash
+	}
+
+	tree := store(&object.Tree{})
+	when := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var head plumbing.Hash
+	for i := 0; i < tags*commitsPerTag; i++ {
+		when = when.Add(time.Hour)
+		sig := object.Signature{Name: "Jane Doe", Email: "jane@example.com", When: when}
+		commit := &object.Commit{
+			Author:    sig,
+			Committer: sig,
+			Message:   fmt.Sprintf("feat(part%d): change number %d\n\nWith a body.\n", i%7, i),
+			TreeHash:  tree,
+		}
+		if !head.IsZero() {
+			commit.ParentHashes = []plumbing.Hash{head}
+		}
+		head = store(commit)
+		if (i+1)%commitsPerTag == 0 {
+			name := plumbing.NewTagReferenceName(fmt.Sprintf("v0.%d.0", i/commitsPerTag))
+			if err := storer.SetReference(plumbing.NewHashReference(name, head)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	return repo, head
+}
+
+// BenchmarkAllTags walks a repository with many releases the way --all-tags
+// does, rendering each release as soon as it's complete, and compares it
+// with holding every release until the walk is over. The retained-B/op
+// metric is the heap still in use when the walk ends.
+func BenchmarkAllTags(b *testing.B) {
+	repo, head := manyTagRepo(b, 200, 10)
+	tagged, err := getTaggedCommits(repo)
+	if err != nil {
+		b.Fatal(err)
+	}
+	abbrev, err := newAbbreviator(repo, 7, false)
+	if err != nil {
+		b.Fatal(err)
+	}
+	tmpl, err := parseTemplate("markdown", "text", releaseTemplate, "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	newGen := func() *generator {
+		return &generator{
+			repo:          repo,
+			remoteURL:     "https://github.com/acme/widget",
+			taggedCommits: tagged,
+			abbrev:        abbrev,
+			filter:        &filters{},
+			withBody:      true,
+			progress:      newProgress(true),
+		}
+	}
+	retained := func() float64 {
+		var stats runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		return float64(stats.HeapAlloc)
+	}
+
+	b.Run("stream", func(b *testing.B) {
+		b.ReportAllocs()
+		var heap float64
+		for i := 0; i < b.N; i++ {
+			err := newGen().walk(head, "", "", true, func(r *Release) error {
+				return tmpl.Execute(io.Discard, r)
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.StopTimer()
+			heap += retained()
+			b.StartTimer()
+		}
+		b.ReportMetric(heap/float64(b.N), "retained-B/op")
+	})
+
+	b.Run("collect", func(b *testing.B) {
+		b.ReportAllocs()
+		var heap float64
+		for i := 0; i < b.N; i++ {
+			var releases []*Release
+			err := newGen().walk(head, "", "", true, func(r *Release) error {
+				releases = append(releases, r)
+				return nil
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.StopTimer()
+			heap += retained()
+			b.StartTimer()
+			for _, r := range releases {
+				if err := tmpl.Execute(io.Discard, r); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		b.ReportMetric(heap/float64(b.N), "retained-B/op")
+	})
+}
+
+func TestNotARepo(t *testing.T) {
+	dir := t.TempDir()
+	out := runSumitError(t, dir, "1.0.0")
+	for _, want := range []string{"not a git repository: " + dir, "pass --dir"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q doesn't mention %q", out, want)
+		}
+	}
+}
+
+func TestVersionAndTagName(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: first")
+	r.tag("v1.0.0")
+	r.commit("feat: second")
+	tmpl := filepath.Join(t.TempDir(), "release.tmpl")
+	err := os.WriteFile(tmpl, []byte("{{ .Version }} {{ .Tag }}{{ range .Changes }} {{ .Title }}{{ end }}"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"tag from version", []string{"2.0.0"}, "2.0.0 v2.0.0 feat: second"},
+		{"prefixed version", []string{"v2.0.0"}, "v2.0.0 v2.0.0 feat: second"},
+		{"existing release", []string{"1.0.0"}, "1.0.0 v1.0.0 feat: first"},
+		{"version from tag", []string{"--tag-name", "v1.0.0"}, "1.0.0 v1.0.0 feat: first"},
+		// v1.0.0 doesn't have the prefix, so it isn't a release boundary
+		{"other prefix", []string{"--tag-prefix", "release-", "--tag-name", "release-2.0.0"}, "2.0.0 release-2.0.0 feat: second feat: first"},
+		{"both", []string{"--tag-name", "nightly", "Nightly build"}, "Nightly build nightly feat: second"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runSumit(t, r.dir, append(tt.args, "--template", tmpl)...); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateSource(t *testing.T) {
+	r := newTestRepo(t)
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// rebased a month after it was written
+	author := &object.Signature{Name: "Jane Doe", Email: "jane@example.com", When: time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)}
+	committer := &object.Signature{Name: "Jane Doe", Email: "jane@example.com", When: time.Date(2024, 2, 3, 12, 0, 0, 0, time.UTC)}
+	if _, err := wt.Commit("feat: rebased", &git.CommitOptions{Author: author, Committer: committer, AllowEmptyCommits: true}); err != nil {
+		t.Fatal(err)
+	}
+	r.tag("v1.0.0")
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"author", "2024-01-02"},
+		{"committer", "2024-02-03"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, "--date-source", tt.source)
+			release := walkReleases(t, gen, head)[0]
+			if release.Date != tt.want || release.Changes[0].Date != tt.want {
+				t.Errorf("got release date %s and change date %s, want %s", release.Date, release.Changes[0].Date, tt.want)
+			}
+		})
+	}
+
+	_, _, err = newTestGenerator(t, r.dir, "--date-source", "tagger")
+	if err == nil || !strings.Contains(err.Error(), "unsupported date-source value: tagger") {
+		t.Errorf("got %v, want an unsupported value error", err)
+	}
+}
+
+func TestTagger(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a")
+	r.tag("v1.0.0")
+	r.commit("feat: b")
+	r.annotatedTag("v1.1.0", "Release Bot", time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC))
+
+	gen, head := testGenerator(t, r.dir)
+	releases := walkReleases(t, gen, head)
+	if len(releases) != 2 {
+		t.Fatalf("got %d releases, want 2", len(releases))
+	}
+	tests := []struct {
+		release            *Release
+		tagger, taggerDate string
+	}{
+		{releases[0], "Release Bot", "2024-03-04"},
+		// lightweight tags have no tagger
+		{releases[1], "", ""},
+	}
+	for _, tt := range tests {
+		if tt.release.Tagger != tt.tagger || tt.release.TaggerDate != tt.taggerDate {
+			t.Errorf("%s tagged by %q on %q, want %q on %q", tt.release.Tag, tt.release.Tagger, tt.release.TaggerDate, tt.tagger, tt.taggerDate)
+		}
+	}
+}
+
+func TestIncludeHashInTitle(t *testing.T) {
+	r := newTestRepo(t)
+	hash := r.commit("feat: add feature")
+	sha := hash.String()[:7]
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"off", nil, "feat: add feature"},
+		{"on", []string{"--include-hash-in-title"}, "feat: add feature (" + sha + ")"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			if got := walkReleases(t, gen, head)[0].Changes[0].Title; got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	out := runSumit(t, r.dir, "--include-hash-in-title", "--output-format", "plain-links", "1.0.0")
+	if !strings.Contains(out, "- feat: add feature ("+sha+")") {
+		t.Errorf("plain output has no hash:\n%s", out)
+	}
+}
+
+func TestStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(r *testRepo)
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "no remote",
+			setup:   func(r *testRepo) { r.commit("feat: a") },
+			wantErr: "can't link changes: no git remote",
+		},
+		{
+			name: "unparseable remote",
+			setup: func(r *testRepo) {
+				r.remote("origin", "file:///srv/git/widget")
+				r.commit("feat: a")
+			},
+			wantErr: "can't link changes",
+		},
+		{
+			name: "empty subject",
+			setup: func(r *testRepo) {
+				r.remote("origin", "https://github.com/acme/widget.git")
+				r.commit("   \n\nbody only")
+			},
+			wantErr: "has an empty subject",
+		},
+		{
+			name: "unsigned commit",
+			setup: func(r *testRepo) {
+				r.remote("origin", "https://github.com/acme/widget.git")
+				r.commit("feat: a")
+			},
+			args:    []string{"--require-signoff"},
+			wantErr: unsignedReason,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepo(t)
+			tt.setup(r)
+
+			// lenient by default
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			walkReleases(t, gen, head)
+
+			gen, head, err := newTestGenerator(t, r.dir, append([]string{"--strict"}, tt.args...)...)
+			if err == nil {
+				err = gen.walk(head, "", "", true, func(*Release) error { return nil })
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestShowStats(t *testing.T) {
+	r := newTestRepo(t)
+	r.commitFile("a.txt", "one\ntwo\nthree\n", "feat: add a")
+	r.commitFile("a.txt", "one\n2\nthree\nfour\n", "fix: change a")
+	r.commitFile("b.txt", "b\n", "feat: add b")
+
+	tests := []struct {
+		name string
+		args []string
+		want []*DiffStats
+	}{
+		{"off", nil, []*DiffStats{nil, nil, nil}},
+		{"on", []string{"--show-stats"}, []*DiffStats{
+			{Files: 1, Insertions: 1, Deletions: 0},
+			{Files: 1, Insertions: 2, Deletions: 1},
+			{Files: 1, Insertions: 3, Deletions: 0},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			var got []*DiffStats
+			for _, change := range walkReleases(t, gen, head)[0].Changes {
+				got = append(got, change.Stats)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffStatsString(t *testing.T) {
+	tests := []struct {
+		stats DiffStats
+		want  string
+	}{
+		{DiffStats{Files: 1, Insertions: 3}, "1 file, +3 -0"},
+		{DiffStats{Files: 2, Insertions: 5, Deletions: 7}, "2 files, +5 -7"},
+	}
+	for _, tt := range tests {
+		if got := tt.stats.String(); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestPinnedClockDatesUnreleasedChanges(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: first")
+	pinNow(t, time.Date(2030, 5, 17, 9, 30, 0, 0, time.UTC))
+
+	out := runSumit(t, r.dir, "1.0.0")
+	if want := "## [1.0.0] - 2030-05-17\n"; !strings.HasPrefix(out, want) {
+		t.Errorf("got %q, want it to start with %q", out, want)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		commits bool
+		args    []string
+		want    error
+		ref     string
+	}{
+		{name: "no commits", want: ErrNoCommits},
+		{name: "missing --ref", commits: true, args: []string{"--ref", "gone"}, want: ErrInvalidRange, ref: "gone"},
+		{name: "missing --to", commits: true, args: []string{"--to", "v9"}, want: ErrInvalidRange, ref: "v9"},
+		{name: "missing --from", commits: true, args: []string{"--from", "v0"}, want: ErrInvalidRange, ref: "v0"},
+		{name: "missing --base", commits: true, args: []string{"--branch-only", "--base", "trunk"}, want: ErrInvalidRange, ref: "trunk"},
+		{name: "missing --compare-from-initial", commits: true, args: []string{"--compare-from-initial=v0"}, want: ErrInvalidRange, ref: "v0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepo(t)
+			if tt.commits {
+				r.commit("feat: a")
+			}
+			_, _, err := newTestGenerator(t, r.dir, tt.args...)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			var refErr *RefError
+			if errors.As(err, &refErr) != (tt.ref != "") || tt.ref != "" && refErr.Ref != tt.ref {
+				t.Errorf("got %#v, want a RefError for %q", err, tt.ref)
+			}
+		})
+	}
+}
+
+func TestDedupeAcrossReleases(t *testing.T) {
+	change := func(sha, title string) Change {
+		return Change{SHA: sha, Title: title, Email: "jane@example.com", Type: strings.SplitN(title, ":", 2)[0]}
+	}
+	// overlapping ranges, newest release first
+	releases := []*Release{
+		{Version: "1.2.0", Changes: []Change{change("c3", "feat: c"), change("c2", "fix: b"), change("c1", "feat: a")}},
+		{Version: "1.1.0", Changes: []Change{change("c2", "fix: b"), {Title: "docs: hand-written"}}},
+		{Version: "1.0.0", Changes: []Change{change("c1", "feat: a"), {Title: "docs: hand-written"}}},
+	}
+	gen := &generator{groupBy: []string{"type"}, domainSummary: true}
+	gen.dedupeAcrossReleases(releases)
+
+	want := map[string][]string{
+		"1.2.0": {"feat: c"},
+		"1.1.0": {"fix: b", "docs: hand-written"},
+		"1.0.0": {"feat: a", "docs: hand-written"},
+	}
+	for _, release := range releases {
+		if got := titles(release.Changes); !reflect.DeepEqual(got, want[release.Version]) {
+			t.Errorf("%s has %q, want %q", release.Version, got, want[release.Version])
+		}
+	}
+	// releases that lost changes are grouped again
+	if groups := releases[0].Groups; len(groups) != 1 || !reflect.DeepEqual(titles(groups[0].Changes), []string{"feat: c"}) {
+		t.Errorf("1.2.0 is grouped as %+v", groups)
+	}
+	if domains := releases[0].Domains; len(domains) != 1 || domains[0].Domain != "example.com" || domains[0].Count != 1 {
+		t.Errorf("1.2.0 counts domains %+v", domains)
+	}
+}
+
+func TestDedupeAcrossReleasesFlag(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a")
+	r.tag("v1.0.0")
+	r.commit("fix: b")
+	r.tag("v1.1.0")
+	r.commit("feat: c")
+
+	want := runSumit(t, r.dir, "--all-tags")
+	if got := runSumit(t, r.dir, "--all-tags", "--dedupe-across-releases"); got != want {
+		t.Errorf("got\n%s\nwant the same releases as without the flag\n%s", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// gitlabRelease is the body of a request to GitLab's Releases API, which
+// release-cli create sends with the values of its --name, --tag-name,
+// --description and --released-at options.
+type gitlabRelease struct {
+	Name        string `json:"name"`
+	TagName     string `json:"tag_name,omitempty"`
+	Description string `json:"description"`
+	ReleasedAt  string `json:"released_at,omitempty"`
+}
+
+// gitlabReleaseRenderer writes a release as a line of JSON for GitLab, with
+// whatever the wrapped renderer produces as its description.
+type gitlabReleaseRenderer struct {
+	renderer
+}
+
+func (r gitlabReleaseRenderer) Execute(w io.Writer, data any) error {
+	release := data.(*Release)
+	var description bytes.Buffer
+	if err := r.renderer.Execute(&description, release); err != nil {
+		return err
+	}
+
+	out := gitlabRelease{
+		Name:        release.Version,
+		TagName:     release.Tag,
+		Description: description.String(),
+	}
+	if release.Tag != "" {
+		out.Name = release.Tag
+	}
+	// GitLab dates releases made without released_at now, and treats
+	// earlier ones as historical, so only past releases are dated. Dates
+	// made relative by --relative-dates are left out too.
+	if date, err := time.Parse("2006-01-02", release.Date); err == nil && release.Date != now().Format("2006-01-02") {
+		out.ReleasedAt = date.Format(time.RFC3339)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(out)
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGitLabRelease(t *testing.T) {
+	r := newTestRepo(t)
+	a := r.commit("feat: a").String()[:7]
+	r.tag("v1.0.0")
+	b := r.commit("fix: <b> & c").String()[:7]
+	fixes := "## What's Changed\n\n### Bug Fixes\n\n* fix: <b> & c by Jane Doe in " + b + "\n"
+	features := "## What's Changed\n\n### Features\n\n* feat: a by Jane Doe in " + a + "\n"
+	pinNow(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name string
+		args []string
+		want []map[string]string
+	}{
+		{
+			name: "new release",
+			args: []string{"1.1.0"},
+			want: []map[string]string{
+				{"name": "v1.1.0", "tag_name": "v1.1.0", "description": fixes},
+			},
+		},
+		{
+			name: "all tags",
+			args: []string{"--all-tags"},
+			want: []map[string]string{
+				{"name": "Unreleased", "description": fixes},
+				{"name": "v1.0.0", "tag_name": "v1.0.0", "description": features, "released_at": "2024-01-01T00:00:00Z"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := runSumit(t, r.dir, append([]string{"--output-format", "gitlab-release"}, tt.args...)...)
+			var got []map[string]string
+			for _, line := range lines(out) {
+				var release map[string]string
+				if err := json.Unmarshal([]byte(line), &release); err != nil {
+					t.Fatalf("line %q isn't a release: %v", line, err)
+				}
+				got = append(got, release)
+			}
+			if !strings.Contains(out, "fix: <b> & c") {
+				t.Errorf("output escapes HTML:\n%s", out)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got\n%q\nwant\n%q\nfrom\n%s", got, tt.want, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// gitmoji maps a gitmoji (https://gitmoji.dev) to the conventional type
+// whose section it belongs in.
+type gitmoji struct {
+	emoji     string
+	shortcode string
+	typ       string
+}
+
+// gitmojis lists the gitmojis that have a clear changelog category. Emoji are
+// written without the U+FE0F variation selector, which is ignored when
+// matching.
+var gitmojis = []gitmoji{
+	{"✨", ":sparkles:", "feat"},
+	{"🎉", ":tada:", "feat"},
+	{"💥", ":boom:", "feat"},
+	{"🐛", ":bug:", "fix"},
+	{"🚑", ":ambulance:", "fix"},
+	{"🩹", ":adhesive_bandage:", "fix"},
+	{"🔒", ":lock:", "fix"},
+	{"⚡", ":zap:", "perf"},
+	{"⏪", ":rewind:", "revert"},
+	{"📝", ":memo:", "docs"},
+	{"♻", ":recycle:", "refactor"},
+	{"🔥", ":fire:", "refactor"},
+	{"🎨", ":art:", "style"},
+	{"✅", ":white_check_mark:", "test"},
+	{"🧪", ":test_tube:", "test"},
+	{"📦", ":package:", "build"},
+	{"⬆", ":arrow_up:", "build"},
+	{"⬇", ":arrow_down:", "build"},
+	{"➕", ":heavy_plus_sign:", "build"},
+	{"➖", ":heavy_minus_sign:", "build"},
+	{"👷", ":construction_worker:", "ci"},
+	{"💚", ":green_heart:", "ci"},
+	{"🔧", ":wrench:", "chore"},
+	{"🔖", ":bookmark:", "chore"},
+}
+
+const variationSelector = "\ufe0f"
+
+// parseGitmoji finds the type of a subject starting with a known gitmoji,
+// either as the emoji itself or as its :shortcode:. 💥 marks a breaking
+// change.
+func parseGitmoji(subject string) (typ string, breaking bool, ok bool) {
+	subject = strings.TrimSpace(subject)
+	for _, g := range gitmojis {
+		if strings.HasPrefix(subject, g.shortcode) || strings.HasPrefix(strings.ReplaceAll(subject, variationSelector, ""), g.emoji) {
+			return g.typ, g.shortcode == ":boom:", true
+		}
+	}
+	return "", false, false
+}
+
+var leadingShortcode = regexp.MustCompile(`^:[a-z0-9_+-]+:`)
+
+// stripLeadingEmoji removes the emoji and :shortcodes: decorating the start
+// of a subject, leaving any that appear later in the text alone. A subject
+// made up of nothing but emoji is returned unchanged.
+func stripLeadingEmoji(subject string) string {
+	rest := strings.TrimLeftFunc(subject, unicode.IsSpace)
+	for {
+		if loc := leadingShortcode.FindStringIndex(rest); loc != nil {
+			rest = rest[loc[1]:]
+		} else if r, size := utf8.DecodeRuneInString(rest); size > 0 && isEmojiRune(r) {
+			rest = rest[size:]
+		} else {
+			break
+		}
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+	}
+	if rest == "" {
+		return subject
+	}
+	return rest
+}
+
+// emojiRanges covers the blocks emoji are drawn from. Other symbols such as
+// ©, ™, arrows and box drawing are left out since they show up in ordinary
+// subjects.
+var emojiRanges = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x231a, Hi: 0x231b, Stride: 1},
+		{Lo: 0x23e9, Hi: 0x23fa, Stride: 1},
+		{Lo: 0x2600, Hi: 0x27bf, Stride: 1},
+		{Lo: 0x2b05, Hi: 0x2b07, Stride: 1},
+		{Lo: 0x2b1b, Hi: 0x2b1c, Stride: 1},
+		{Lo: 0x2b50, Hi: 0x2b55, Stride: 5},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f000, Hi: 0x1faff, Stride: 1},
+	},
+}
+
+// isEmojiRune reports whether r is an emoji or one of the joiners and
+// modifiers that combine with one into a single emoji.
+func isEmojiRune(r rune) bool {
+	switch {
+	case r == 0x200d, r == 0xfe0f, r == 0x20e3:
+		return true
+	case r >= 0x1f3fb && r <= 0x1f3ff:
+		return true
+	}
+	return unicode.Is(emojiRanges, r)
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGitmoji(t *testing.T) {
+	tests := []struct {
+		subject  string
+		typ      string
+		breaking bool
+		ok       bool
+	}{
+		{"✨ add search", "feat", false, true},
+		{":sparkles: add search", "feat", false, true},
+		{"🐛 fix crash", "fix", false, true},
+		{":bug: fix crash", "fix", false, true},
+		{"♻️ simplify parser", "refactor", false, true},
+		{"♻ simplify parser", "refactor", false, true},
+		{"💥 drop v1 API", "feat", true, true},
+		{":boom: drop v1 API", "feat", true, true},
+		{"  ⚡ faster startup", "perf", false, true},
+		{":unknown: something", "", false, false},
+		{"add ✨ later", "", false, false},
+	}
+	for _, tt := range tests {
+		typ, breaking, ok := parseGitmoji(tt.subject)
+		if typ != tt.typ || breaking != tt.breaking || ok != tt.ok {
+			t.Errorf("parseGitmoji(%q) = %q, %v, %v, want %q, %v, %v", tt.subject, typ, breaking, ok, tt.typ, tt.breaking, tt.ok)
+		}
+	}
+}
+
+func TestGitmojiFlag(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit(":bug: fix crash")
+	r.commit("✨ add search")
+	r.commit("fix: conventional wins")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"without flag", nil, []string{"fix", "", ""}},
+		{"with flag", []string{"--gitmoji"}, []string{"fix", "feat", "fix"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			var got []string
+			for _, change := range walkReleases(t, gen, head)[0].Changes {
+				got = append(got, change.Type)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripLeadingEmoji(t *testing.T) {
+	tests := []struct {
+		subject, want string
+	}{
+		{"✨ add search", "add search"},
+		{":sparkles: add search", "add search"},
+		{"♻️ :fire: tidy up", "tidy up"},
+		{"👩‍💻 pair on parser", "pair on parser"},
+		{"add 🎉 confetti", "add 🎉 confetti"},
+		{"fix: handle :bug: shortcode text", "fix: handle :bug: shortcode text"},
+		{"🎉", "🎉"},
+		{"⏪ revert the parser", "revert the parser"},
+		{"⬆️ bump go-git", "bump go-git"},
+		{"plain subject", "plain subject"},
+		// symbols that aren't emoji are part of the subject
+		{"© 2024 in the footer", "© 2024 in the footer"},
+		{"™ sign in the title", "™ sign in the title"},
+		{"→ arrow keys move focus", "→ arrow keys move focus"},
+		{"─ separator in tables", "─ separator in tables"},
+	}
+	for _, tt := range tests {
+		if got := stripLeadingEmoji(tt.subject); got != tt.want {
+			t.Errorf("stripLeadingEmoji(%q) = %q, want %q", tt.subject, got, tt.want)
+		}
+	}
+}
+
+func TestStripEmojiFlag(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit(":bug: fix crash")
+	r.commit("✨ add 🎉 confetti")
+
+	gen, head := testGenerator(t, r.dir, "--strip-emoji", "--gitmoji")
+	changes := walkReleases(t, gen, head)[0].Changes
+	if got, want := titles(changes), []string{"add 🎉 confetti", "fix crash"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// the emoji are stripped after they've given the changes their types
+	if changes[0].Type != "feat" || changes[1].Type != "fix" {
+		t.Errorf("got types %q and %q, want feat and fix", changes[0].Type, changes[1].Type)
+	}
+}
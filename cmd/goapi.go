@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const goAPIReason = "no exported Go API change"
+
+// changesGoAPI reports whether a commit added, removed or changed an
+// exported declaration in a Go file, going by its diff against its first
+// parent. Both versions of every changed file are parsed and their exported
+// declarations compared, so changes to function bodies, unexported code and
+// comments don't count. Tests and internal packages aren't part of the API.
+// A commit whose diff can't be computed, or whose Go files don't parse, is
+// assumed to change it.
+func changesGoAPI(c *object.Commit) bool {
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return true
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return true
+		}
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return true
+	}
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return true
+	}
+
+	for _, change := range changes {
+		name := change.To.Name
+		if name == "" {
+			name = change.From.Name
+		}
+		if !isGoAPIFile(name) {
+			continue
+		}
+		from, to, err := change.Files()
+		if err != nil {
+			return true
+		}
+		before, err := fileAPI(from)
+		if err != nil {
+			return true
+		}
+		after, err := fileAPI(to)
+		if err != nil {
+			return true
+		}
+		if !sameAPI(before, after) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileAPI lists the exported declarations of a Go file, or none when the
+// file doesn't exist on that side of the diff.
+func fileAPI(f *object.File) (map[string]bool, error) {
+	if f == nil {
+		return nil, nil
+	}
+	src, err := f.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return exportedAPI(src)
+}
+
+// exportedAPI describes each exported declaration of a Go source file as a
+// string holding its name and type: functions and methods of exported
+// types with their signatures, exported types, the exported fields of
+// structs and methods of interfaces, and exported variables and constants
+// with their declared types. Values and function bodies are left out, as
+// changing them doesn't change the API, and so is package main.
+func exportedAPI(src string) (map[string]bool, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	api := make(map[string]bool)
+	if file.Name.Name == "main" {
+		// nothing can import a command
+		return api, nil
+	}
+	text := func(node ast.Node) string {
+		if node == nil {
+			return ""
+		}
+		var buf bytes.Buffer
+		_ = printer.Fprint(&buf, fset, node)
+		return buf.String()
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if !decl.Name.IsExported() {
+				continue
+			}
+			name := decl.Name.Name
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				recv := receiverName(decl.Recv.List[0].Type)
+				if !ast.IsExported(recv) {
+					continue
+				}
+				name = recv + "." + name
+			}
+			api["func "+name+" "+text(decl.Type)] = true
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if !spec.Name.IsExported() {
+						continue
+					}
+					typeAPI(api, spec, text)
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						if name.IsExported() {
+							api[decl.Tok.String()+" "+name.Name+" "+text(spec.Type)] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return api, nil
+}
+
+// typeAPI adds an exported type to api. Structs and interfaces are listed
+// member by member, so only their exported members count.
+func typeAPI(api map[string]bool, spec *ast.TypeSpec, text func(ast.Node) string) {
+	name := "type " + spec.Name.Name + text(spec.TypeParams)
+	if spec.Assign.IsValid() {
+		name += " ="
+	}
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		api[name+" struct"] = true
+		for _, field := range t.Fields.List {
+			if len(field.Names) == 0 {
+				// embedded fields are promoted, so their type matters
+				api[name+" embeds "+text(field.Type)] = true
+				continue
+			}
+			for _, fieldName := range field.Names {
+				if fieldName.IsExported() {
+					api[name+" field "+fieldName.Name+" "+text(field.Type)] = true
+				}
+			}
+		}
+	case *ast.InterfaceType:
+		api[name+" interface"] = true
+		for _, method := range t.Methods.List {
+			if len(method.Names) == 0 {
+				api[name+" embeds "+text(method.Type)] = true
+				continue
+			}
+			for _, methodName := range method.Names {
+				// unexported methods still stop other packages from
+				// implementing the interface
+				api[name+" method "+methodName.Name+" "+text(method.Type)] = true
+			}
+		}
+	default:
+		api[name+" "+text(spec.Type)] = true
+	}
+}
+
+// receiverName returns the name of a method's receiver type, without the
+// pointer and type parameters.
+func receiverName(expr ast.Expr) string {
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.IndexExpr:
+			expr = t.X
+		case *ast.IndexListExpr:
+			expr = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// sameAPI reports whether two files declare the same exported API.
+func sameAPI(a, b map[string]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for decl := range a {
+		if !b[decl] {
+			return false
+		}
+	}
+	return true
+}
+
+// isGoAPIFile reports whether a file's exported declarations can be used
+// by other modules.
+func isGoAPIFile(name string) bool {
+	if path.Ext(name) != ".go" || strings.HasSuffix(name, "_test.go") {
+		return false
+	}
+	for _, dir := range strings.Split(path.Dir(name), "/") {
+		if dir == "internal" || dir == "testdata" || dir == "vendor" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import "testing"
+
+const goAPIBase = `package widget
+
+// Widget does things.
+type Widget struct {
+	Name string
+	size int
+}
+
+type Sizer interface {
+	Size() int
+}
+
+const Version = "1.0.0"
+
+var ErrBroken = errors.New("broken")
+
+func New(name string) *Widget {
+	return &Widget{Name: name}
+}
+
+func (w *Widget) Size() int { return w.size }
+
+func helper() {}
+`
+
+func TestExportedAPIChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		change string
+		api    bool
+	}{
+		{"same file", goAPIBase, false},
+		{"function body", replace(goAPIBase, "return &Widget{Name: name}", "w := &Widget{Name: name}\n\tw.size = 1\n\treturn w"), false},
+		{"struct literal in a body", replace(goAPIBase, "func helper() {}", "func helper() {\n\t_ = Widget{\n\t\tName: \"x\",\n\t}\n\tDoThing(1)\n}"), false},
+		{"comment", replace(goAPIBase, "// Widget does things.", "// Widget does things.\n// If you need more, ask."), false},
+		{"unexported field", replace(goAPIBase, "size int", "size int64"), false},
+		{"unexported function", replace(goAPIBase, "func helper() {}", "func helper(n int) {}"), false},
+		{"constant value", replace(goAPIBase, `"1.0.0"`, `"1.1.0"`), false},
+		{"exported field", replace(goAPIBase, "Name string", "Name string\n\tColor string"), true},
+		{"field type", replace(goAPIBase, "Name string", "Name []byte"), true},
+		{"function signature", replace(goAPIBase, "func New(name string)", "func New(name string, size int)"), true},
+		{"new function", goAPIBase + "\nfunc Old() {}\n", true},
+		{"removed method", replace(goAPIBase, "func (w *Widget) Size() int { return w.size }", ""), true},
+		{"interface method", replace(goAPIBase, "Size() int\n}", "Size() int\n\tReset()\n}"), true},
+		{"variable", replace(goAPIBase, "var ErrBroken", "var ErrGone = errors.New(\"gone\")\n\nvar ErrBroken"), true},
+	}
+	before, err := exportedAPI(goAPIBase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after, err := exportedAPI(tt.change)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := !sameAPI(before, after); got != tt.api {
+				t.Errorf("API change = %v, want %v", got, tt.api)
+			}
+		})
+	}
+}
+
+func TestExportedAPIIgnoresMain(t *testing.T) {
+	api, err := exportedAPI("package main\n\nfunc Run() {}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(api) != 0 {
+		t.Errorf("got %v, want no API", api)
+	}
+}
+
+func TestGoAPIOnly(t *testing.T) {
+	r := newTestRepo(t)
+	r.commitFile("widget.go", goAPIBase, "feat: add widget")
+	r.commitFile("widget.go", replace(goAPIBase, "return &Widget{Name: name}", "return &Widget{Name: name, size: 1}"), "fix: default size")
+	r.commitFile("widget.go", replace(goAPIBase, "func New(name string)", "func New(name string, size int)"), "feat!: take a size")
+	r.commitFile("internal/x.go", "package x\n\nfunc X() {}\n", "refactor: add internal helper")
+	r.commitFile("README.md", "# widget\n", "docs: readme")
+
+	gen, head := testGenerator(t, r.dir, "--go-api-only")
+	got := titles(walkReleases(t, gen, head)[0].Changes)
+	want := []string{"feat!: take a size", "feat: add widget"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestIsGoAPIFile(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"widget.go", true},
+		{"pkg/widget/widget.go", true},
+		{"widget_test.go", false},
+		{"internal/widget.go", false},
+		{"pkg/internal/x/widget.go", false},
+		{"testdata/widget.go", false},
+		{"vendor/example.com/x/x.go", false},
+		{"README.md", false},
+	}
+	for _, tt := range tests {
+		if got := isGoAPIFile(tt.name); got != tt.want {
+			t.Errorf("isGoAPIFile(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxGroupDepth is how many levels --group-by can nest.
+const maxGroupDepth = 2
+
+const (
+	breakingGroupTitle = "Breaking Changes"
+	otherGroupTitle    = "Other Changes"
+	unknownDomain      = "(no email)"
+)
+
+// typeOrder lists conventional types in the order their sections appear.
+// Types missing from this list are placed after it, alphabetically.
+var typeOrder = []string{"feat", "fix", "perf", "revert", "docs", "refactor", "style", "test", "build", "ci", "chore"}
+
+var typeTitles = map[string]string{
+	"feat":     "Features",
+	"fix":      "Bug Fixes",
+	"perf":     "Performance Improvements",
+	"revert":   "Reverts",
+	"docs":     "Documentation",
+	"refactor": "Code Refactoring",
+	"style":    "Styles",
+	"test":     "Tests",
+	"build":    "Build System",
+	"ci":       "Continuous Integration",
+	"chore":    "Chores",
+}
+
+// parseGroupBy splits a --group-by value such as "type,scope" into its
+// levels, outermost first.
+func parseGroupBy(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	levels := strings.Split(value, ",")
+	if len(levels) > maxGroupDepth {
+		return nil, errors.New(fmt.Sprintf("group-by supports at most %d levels: %s", maxGroupDepth, value))
+	}
+	seen := make(map[string]bool)
+	for i, level := range levels {
+		level = strings.TrimSpace(level)
+		switch level {
+		case "author", "type", "scope":
+		default:
+			return nil, errors.New(fmt.Sprintf("unsupported group-by value: %s", level))
+		}
+		if seen[level] {
+			return nil, errors.New(fmt.Sprintf("group-by repeats %s", level))
+		}
+		seen[level] = true
+		levels[i] = level
+	}
+	return levels, nil
+}
+
+// groupChanges buckets changes by the first of the given levels, then the
+// changes in each group by the next level, and so on. titles overrides the
+// section titles of conventional types, and the types in keep get a section
+// even when they have no changes.
+func groupChanges(changes []Change, levels []string, titles map[string]string, keep []string) []Group {
+	if len(levels) == 0 {
+		return nil
+	}
+	var groups []Group
+	switch levels[0] {
+	case "author":
+		groups = groupByAuthor(changes)
+	case "type":
+		groups = groupByType(changes, titles, keep)
+	case "scope":
+		groups = groupByScope(changes)
+	}
+	for i := range groups {
+		groups[i].Groups = groupChanges(groups[i].Changes, levels[1:], titles, keep)
+	}
+	return groups
+}
+
+// groupByAuthor buckets changes under each author's name, ordering authors
+// by number of changes (descending) and then alphabetically.
+func groupByAuthor(changes []Change) []Group {
+	var groups []Group
+	index := make(map[string]int)
+	for _, change := range changes {
+		i, ok := index[change.Author]
+		if !ok {
+			i = len(groups)
+			index[change.Author] = i
+			groups = append(groups, Group{Title: change.Author})
+		}
+		groups[i].Changes = append(groups[i].Changes, change)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i].Changes) != len(groups[j].Changes) {
+			return len(groups[i].Changes) > len(groups[j].Changes)
+		}
+		return groups[i].Title < groups[j].Title
+	})
+	return groups
+}
+
+// groupByType buckets changes into sections by conventional type. Breaking
+// changes get their own leading section and commits that don't follow the
+// convention are collected under a trailing "Other Changes" section. Types
+// without changes are left out, except for those in keep.
+func groupByType(changes []Change, titles map[string]string, keep []string) []Group {
+	byType := make(map[string][]Change)
+	var breaking, other []Change
+	for _, change := range changes {
+		switch {
+		case change.Breaking:
+			breaking = append(breaking, change)
+		case change.Type == "":
+			other = append(other, change)
+		default:
+			byType[change.Type] = append(byType[change.Type], change)
+		}
+	}
+	for _, t := range keep {
+		if _, ok := byType[t]; !ok {
+			byType[t] = []Change{}
+		}
+	}
+
+	var groups []Group
+	if len(breaking) > 0 {
+		groups = append(groups, Group{Title: typeTitle("breaking", titles), Changes: breaking})
+	}
+	for _, t := range sortedTypes(byType) {
+		groups = append(groups, Group{Title: typeTitle(t, titles), Changes: byType[t]})
+	}
+	if len(other) > 0 {
+		groups = append(groups, Group{Title: otherGroupTitle, Changes: other})
+	}
+	return groups
+}
+
+// groupByScope buckets changes by conventional scope, alphabetically, with
+// unscoped changes collected under a trailing "Other Changes" section.
+func groupByScope(changes []Change) []Group {
+	byScope := make(map[string][]Change)
+	var other []Change
+	for _, change := range changes {
+		if change.Scope == "" {
+			other = append(other, change)
+			continue
+		}
+		byScope[change.Scope] = append(byScope[change.Scope], change)
+	}
+
+	scopes := make([]string, 0, len(byScope))
+	for scope := range byScope {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+
+	var groups []Group
+	for _, scope := range scopes {
+		groups = append(groups, Group{Title: scope, Changes: byScope[scope]})
+	}
+	if len(other) > 0 {
+		groups = append(groups, Group{Title: otherGroupTitle, Changes: other})
+	}
+	return groups
+}
+
+// sortByPriority orders changes the way groupByType orders its sections:
+// breaking changes first, then by type, then commits that don't follow the
+// convention, keeping the order of the changes within each.
+func sortByPriority(changes []Change) []Change {
+	sorted := make([]Change, 0, len(changes))
+	for _, group := range groupByType(changes, nil, nil) {
+		sorted = append(sorted, group.Changes...)
+	}
+	return sorted
+}
+
+// countDomains counts changes by the domain of their author's email,
+// ordered like groupByAuthor. Authors without a usable email are counted
+// under unknownDomain.
+func countDomains(changes []Change) []DomainCount {
+	var counts []DomainCount
+	index := make(map[string]int)
+	for _, change := range changes {
+		domain := emailDomain(change.Email)
+		i, ok := index[domain]
+		if !ok {
+			i = len(counts)
+			index[domain] = i
+			counts = append(counts, DomainCount{Domain: domain})
+		}
+		counts[i].Count++
+	}
+
+	sort.SliceStable(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Domain < counts[j].Domain
+	})
+	return counts
+}
+
+// emailDomain returns the lower-cased part of an address after its last @.
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	domain := strings.ToLower(strings.TrimSpace(email[at+1:]))
+	if at < 0 || domain == "" {
+		return unknownDomain
+	}
+	return domain
+}
+
+func sortedTypes(byType map[string][]Change) []string {
+	rank := make(map[string]int, len(typeOrder))
+	for i, t := range typeOrder {
+		rank[t] = i
+	}
+
+	types := make([]string, 0, len(byType))
+	for t := range byType {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		ri, iKnown := rank[types[i]]
+		rj, jKnown := rank[types[j]]
+		switch {
+		case iKnown && jKnown:
+			return ri < rj
+		case iKnown != jKnown:
+			return iKnown
+		}
+		return types[i] < types[j]
+	})
+	return types
+}
+
+// typeTitle is the section title of a conventional type, or of breaking
+// changes for "breaking": the one given with --type-title, else the built-in
+// one, else the type with its first letter upper-cased.
+func typeTitle(t string, titles map[string]string) string {
+	if title, ok := titles[t]; ok {
+		return title
+	}
+	if t == "breaking" {
+		return breakingGroupTitle
+	}
+	if title, ok := typeTitles[t]; ok {
+		return title
+	}
+	if t == "" {
+		return otherGroupTitle
+	}
+	return strings.ToUpper(t[:1]) + t[1:]
+}
+
+// parseTypeTitles turns --type-title type=title pairs into the titles
+// typeTitle looks up.
+func parseTypeTitles(pairs []string) (map[string]string, error) {
+	titles := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		t, title, ok := strings.Cut(pair, "=")
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !ok || t == "" || strings.TrimSpace(title) == "" {
+			return nil, errors.New(fmt.Sprintf("invalid type-title value %q; expected type=title", pair))
+		}
+		titles[t] = strings.TrimSpace(title)
+	}
+	return titles, nil
+}
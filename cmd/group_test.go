@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGroupBy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr string
+	}{
+		{"", nil, ""},
+		{"type", []string{"type"}, ""},
+		{"type, scope", []string{"type", "scope"}, ""},
+		{"author,type", []string{"author", "type"}, ""},
+		{"type,scope,author", nil, "at most 2 levels"},
+		{"type,type", nil, "repeats type"},
+		{"date", nil, "unsupported group-by value"},
+	}
+	for _, tt := range tests {
+		got, err := parseGroupBy(tt.value)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseGroupBy(%q) error = %v, want %q", tt.value, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseGroupBy(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestGroupByTypeThenScope(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat(api): paginate")
+	r.commit("fix(ui): align button")
+	r.commit("feat(ui): add button")
+	r.commit("feat: no scope")
+
+	out := runSumit(t, r.dir, "--group-by", "type,scope", "1.0.0")
+	var headings []string
+	for _, line := range lines(out) {
+		if strings.HasPrefix(line, "###") {
+			headings = append(headings, line)
+		}
+	}
+	want := []string{
+		"### Features",
+		"#### api",
+		"#### ui",
+		"#### Other Changes",
+		"### Bug Fixes",
+		"#### ui",
+	}
+	if !reflect.DeepEqual(headings, want) {
+		t.Errorf("got headings\n%q\nwant\n%q\nin\n%s", headings, want, out)
+	}
+}
+
+func TestEmailDomain(t *testing.T) {
+	tests := []struct {
+		email, want string
+	}{
+		{"jane@example.com", "example.com"},
+		{"John@Corp.EXAMPLE.com ", "corp.example.com"},
+		{"odd@name@example.org", "example.org"},
+		{"", unknownDomain},
+		{"no-at-sign", unknownDomain},
+		{"trailing@", unknownDomain},
+	}
+	for _, tt := range tests {
+		if got := emailDomain(tt.email); got != tt.want {
+			t.Errorf("emailDomain(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestCountDomains(t *testing.T) {
+	changes := []Change{
+		{Email: "a@corp.com"},
+		{Email: "b@oss.org"},
+		{Email: "c@CORP.com"},
+		{Email: ""},
+		{Email: "d@ext.net"},
+	}
+	want := []DomainCount{
+		{Domain: "corp.com", Count: 2},
+		{Domain: unknownDomain, Count: 1},
+		{Domain: "ext.net", Count: 1},
+		{Domain: "oss.org", Count: 1},
+	}
+	if got := countDomains(changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDomainSummary(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a")
+	r.commit("fix: b")
+
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{"without flag", nil, false},
+		{"with flag", []string{"--domain-summary"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := runSumit(t, r.dir, append([]string{"1.0.0"}, tt.args...)...)
+			got := strings.Contains(out, "### Changes by Email Domain\n\n- example.com: 2\n")
+			if got != tt.want {
+				t.Errorf("summary shown %v, want %v:\n%s", got, tt.want, out)
+			}
+		})
+	}
+}
+
+func TestSortByPriority(t *testing.T) {
+	changes := []Change{
+		{Title: "chore: tidy", Type: "chore"},
+		{Title: "plain commit"},
+		{Title: "fix: first fix", Type: "fix"},
+		{Title: "wip: custom type", Type: "wip"},
+		{Title: "perf: faster", Type: "perf"},
+		{Title: "feat: a", Type: "feat"},
+		{Title: "fix!: breaking fix", Type: "fix", Breaking: true},
+		{Title: "fix: second fix", Type: "fix"},
+	}
+	want := []string{
+		"fix!: breaking fix",
+		"feat: a",
+		"fix: first fix",
+		"fix: second fix",
+		"perf: faster",
+		"chore: tidy",
+		"wip: custom type",
+		"plain commit",
+	}
+	if got := titles(sortByPriority(changes)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestPrioritySortFlag(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("fix: b")
+	r.commit("feat: a")
+	r.commit("docs: c")
+	r.commit("refactor!: d")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"commit order", nil, []string{"refactor!: d", "docs: c", "feat: a", "fix: b"}},
+		{"priority", []string{"--priority-sort"}, []string{"refactor!: d", "feat: a", "fix: b", "docs: c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			if got := titles(walkReleases(t, gen, head)[0].Changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTypeTitles(t *testing.T) {
+	tests := []struct {
+		pairs   []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{nil, map[string]string{}, false},
+		{[]string{"feat=New Stuff", " FIX = Repairs "}, map[string]string{"feat": "New Stuff", "fix": "Repairs"}, false},
+		{[]string{"breaking=Heads Up = Read This"}, map[string]string{"breaking": "Heads Up = Read This"}, false},
+		{[]string{"feat"}, nil, true},
+		{[]string{"feat= "}, nil, true},
+		{[]string{"=Title"}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseTypeTitles(tt.pairs)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseTypeTitles(%q) = %v, %v, want %v", tt.pairs, got, err, tt.want)
+		}
+	}
+}
+
+func TestTypeTitleFlag(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a")
+	r.commit("fix!: b")
+	r.commit("wip: c")
+
+	tests := []struct {
+		name   string
+		args   []string
+		config string
+		want   map[string]int
+	}{
+		{"defaults", nil, "", map[string]int{"Breaking Changes": 1, "Features": 1, "Wip": 1}},
+		{"flags", []string{"--type-title", "feat=New Stuff", "--type-title", "breaking=Heads Up"}, "", map[string]int{"Heads Up": 1, "New Stuff": 1, "Wip": 1}},
+		{"config", nil, "type-title:\n  - wip=In Progress\n", map[string]int{"Breaking Changes": 1, "Features": 1, "In Progress": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.writeFile(configFile, tt.config)
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			gen.groupBy = []string{"type"}
+			if got := groupTitles(walkReleases(t, gen, head)[0].Groups); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupByTypeKeepsEmptySections(t *testing.T) {
+	changes := []Change{
+		{Title: "feat: a", Type: "feat"},
+		{Title: "fix!: b", Type: "fix", Breaking: true},
+		{Title: "misc"},
+	}
+	tests := []struct {
+		name string
+		keep []string
+		want map[string]int
+	}{
+		{"none kept", nil, map[string]int{"Breaking Changes": 1, "Features": 1, "Other Changes": 1}},
+		{"kept types", []string{"feat", "docs"}, map[string]int{"Breaking Changes": 1, "Features": 1, "Documentation": 0, "Other Changes": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := groupTitles(groupByType(changes, nil, tt.keep))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeepEmptySectionsNormalizesIncludeTypes(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a")
+
+	tests := []struct {
+		name         string
+		includeTypes string
+		want         []string
+	}{
+		{"trailing comma", "feat,", []string{"feat"}},
+		{"upper case", "FEAT, Docs", []string{"docs", "feat"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, "--keep-empty-sections", "--include-types", tt.includeTypes)
+			gen.groupBy = []string{"type"}
+			got := groupTitles(walkReleases(t, gen, head)[0].Groups)
+			want := make(map[string]int)
+			for _, typ := range tt.want {
+				want[typeTitle(typ, nil)] = 0
+			}
+			want["Features"] = 1
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestReproducibleGroupedOutput(t *testing.T) {
+	r := newTestRepo(t)
+	for _, msg := range []string{
+		"feat(api): a", "fix(cli): b", "perf(db): c", "feat(web): d", "docs: e",
+		"chore(deps): f", "refactor(api): g", "Untyped h", "test(cli): i", "build: j",
+	} {
+		r.commit(msg + "\n\nReviewed-by: Bob <bob@example.com>\nRefs: #1\nTested-by: CI")
+	}
+	r.commitBy("Al", "al@other.org", "feat(ops): k")
+	r.commitBy("Cy", "cy@third.io", "fix(ops): l")
+	pinNow(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"markdown by type and scope", []string{"--group-by", "type,scope", "--domain-summary"}},
+		{"markdown by author", []string{"--group-by", "author"}},
+		{"json", []string{"--group-by", "scope,type", "--output-format", "json", "--with-body"}},
+		{"yaml", []string{"--group-by", "type", "--output-format", "yaml", "--with-body"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append(tt.args, "1.0.0")
+			first := runSumit(t, r.dir, args...)
+			for i := 0; i < 10; i++ {
+				if out := runSumit(t, r.dir, args...); out != first {
+					t.Fatalf("run %d differs:\n%s\nfrom the first:\n%s", i+2, out, first)
+				}
+			}
+		})
+	}
+}
+
+func TestTypeTitle(t *testing.T) {
+	tests := []struct {
+		typ    string
+		titles map[string]string
+		want   string
+	}{
+		{"feat", nil, "Features"},
+		{"breaking", nil, "Breaking Changes"},
+		{"wip", nil, "Wip"},
+		{"feat", map[string]string{"feat": "New Stuff"}, "New Stuff"},
+		{"", nil, "Other Changes"},
+	}
+	for _, tt := range tests {
+		if got := typeTitle(tt.typ, tt.titles); got != tt.want {
+			t.Errorf("typeTitle(%q) = %q, want %q", tt.typ, got, tt.want)
+		}
+	}
+}
+
+// groupTitles maps each group's title to its number of changes.
+func groupTitles(groups []Group) map[string]int {
+	counts := make(map[string]int, len(groups))
+	for _, g := range groups {
+		counts[g.Title] = len(g.Changes)
+	}
+	return counts
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(hasChangesCmd)
+}
+
+var hasChangesCmd = &cobra.Command{
+	Use:   "has-changes",
+	Short: "Exit with 0 when there are changes since the last tag and 1 otherwise",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		gen, head, err := newGenerator(cmd)
+		bail(err)
+		found, err := hasChanges(gen, head)
+		bail(err)
+		if !found {
+			os.Exit(1)
+		}
+	},
+}
+
+// hasChanges reports whether any commits that pass the filters were made
+// since the last tag. Hand-written entries don't count on their own.
+func hasChanges(gen *generator, head plumbing.Hash) (bool, error) {
+	// a tagged HEAD has just been released
+	if _, ok := gen.taggedCommits[head.String()]; ok {
+		return false, nil
+	}
+
+	var found bool
+	err := gen.walk(head, "", "", false, func(release *Release) error {
+		for _, change := range release.Changes {
+			if !change.Fragment {
+				found = true
+				break
+			}
+		}
+		return ErrStopIteration
+	})
+	return found, err
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import "testing"
+
+func TestHasChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		commits []string
+		tagHead bool
+		args    []string
+		want    bool
+	}{
+		{"changes since tag", []string{"feat: a"}, false, nil, true},
+		{"tagged head", nil, true, nil, false},
+		{"changes filtered out", []string{"chore: a", "docs: b"}, false, []string{"--include-types", "feat,fix"}, false},
+		{"qualifying change", []string{"chore: a", "fix: b"}, false, []string{"--include-types", "feat,fix"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepo(t)
+			r.commit("feat: first")
+			r.tag("v1.0.0")
+			for _, msg := range tt.commits {
+				r.commit(msg)
+			}
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			got, err := hasChanges(gen, head)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
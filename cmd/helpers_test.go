@@ -0,0 +1,356 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/pflag"
+)
+
+// testRepo is a repository in a temporary directory whose commits are made
+// a minute apart, so they're ordered the same way on every run.
+type testRepo struct {
+	t    testing.TB
+	dir  string
+	repo *git.Repository
+	when time.Time
+}
+
+func newTestRepo(t testing.TB) *testRepo {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testRepo{t: t, dir: dir, repo: repo, when: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+// commit makes an empty commit with message on HEAD.
+func (r *testRepo) commit(message string) plumbing.Hash {
+	r.t.Helper()
+	return r.commitBy("Jane Doe", "jane@example.com", message)
+}
+
+// commitBy makes an empty commit with message on HEAD, authored by name
+// and email.
+func (r *testRepo) commitBy(name, email, message string) plumbing.Hash {
+	r.t.Helper()
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	r.when = r.when.Add(time.Minute)
+	sig := &object.Signature{Name: name, Email: email, When: r.when}
+	hash, err := wt.Commit(message, &git.CommitOptions{Author: sig, Committer: sig, AllowEmptyCommits: true})
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return hash
+}
+
+// merge makes a merge commit with message on HEAD, whose second parent is
+// other.
+func (r *testRepo) merge(other plumbing.Hash, message string) plumbing.Hash {
+	r.t.Helper()
+	head, err := r.repo.Head()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	r.when = r.when.Add(time.Minute)
+	sig := &object.Signature{Name: "Jane Doe", Email: "jane@example.com", When: r.when}
+	hash, err := wt.Commit(message, &git.CommitOptions{
+		Author:            sig,
+		Committer:         sig,
+		Parents:           []plumbing.Hash{head.Hash(), other},
+		AllowEmptyCommits: true,
+	})
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return hash
+}
+
+// reset moves the current branch to hash, as git reset --soft does.
+func (r *testRepo) reset(hash plumbing.Hash) {
+	r.t.Helper()
+	head, err := r.repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	if err := r.repo.Storer.SetReference(plumbing.NewHashReference(head.Target(), hash)); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// commitFile writes a file, creating its directory, and commits it.
+func (r *testRepo) commitFile(name, content, message string) plumbing.Hash {
+	r.t.Helper()
+	r.writeFile(name, content)
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	if _, err := wt.Add(name); err != nil {
+		r.t.Fatal(err)
+	}
+	return r.commit(message)
+}
+
+// writeFile writes a file in the worktree without committing it.
+func (r *testRepo) writeFile(name, content string) {
+	r.t.Helper()
+	path := filepath.Join(r.dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// tag makes a lightweight tag on HEAD.
+func (r *testRepo) tag(name string) {
+	r.t.Helper()
+	head, err := r.repo.Head()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	if _, err := r.repo.CreateTag(name, head.Hash(), nil); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// annotatedTag makes an annotated tag on HEAD, tagged by tagger at when.
+func (r *testRepo) annotatedTag(name, tagger string, when time.Time) {
+	r.t.Helper()
+	head, err := r.repo.Head()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	_, err = r.repo.CreateTag(name, head.Hash(), &git.CreateTagOptions{
+		Tagger:  &object.Signature{Name: tagger, Email: "tagger@example.com", When: when},
+		Message: "Release " + name,
+	})
+	if err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// remote adds a remote called name with url.
+func (r *testRepo) remote(name, url string) {
+	r.t.Helper()
+	_, err := r.repo.CreateRemote(&config.RemoteConfig{Name: name, URLs: []string{url}})
+	if err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// resetFlags puts every flag back to its default, since cobra keeps the
+// values of one run for the next, and forgets the loaded config.
+func resetFlags() {
+	reset := func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			_ = slice.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	rootCmd.PersistentFlags().VisitAll(reset)
+	for _, cmd := range rootCmd.Commands() {
+		cmd.Flags().VisitAll(reset)
+	}
+	configured = nil
+}
+
+// runSumit runs sumit in dir with args and returns what it wrote to stdout.
+// Errors make sumit exit, so only runs that succeed can be tested this way.
+func runSumit(t testing.TB, dir string, args ...string) string {
+	t.Helper()
+	resetFlags()
+	out, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	stdout := os.Stdout
+	os.Stdout = out
+	defer func() { os.Stdout = stdout }()
+	silenceStderr(t)
+
+	rootCmd.SetArgs(append([]string{"--dir", dir, "--quiet"}, args...))
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	text, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(text)
+}
+
+// runSumitError runs sumit in dir with args in a child process, since a
+// failing run exits, and returns everything it printed. The test fails if
+// the run succeeds.
+func runSumitError(t testing.TB, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSumitProcess$")
+	cmd.Env = append(os.Environ(), "SUMIT_TEST_ARGS="+strings.Join(append([]string{"--dir", dir, "--quiet"}, args...), "\n"))
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("sumit %s succeeded, want it to fail", strings.Join(args, " "))
+	} else if _, ok := err.(*exec.ExitError); !ok {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+// TestSumitProcess is the child process started by runSumitError.
+func TestSumitProcess(t *testing.T) {
+	args, ok := os.LookupEnv("SUMIT_TEST_ARGS")
+	if !ok {
+		return
+	}
+	rootCmd.SetArgs(strings.Split(args, "\n"))
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// testGenerator sets up a generator for the repo in dir with args, as
+// sumit would, without running the command.
+func testGenerator(t testing.TB, dir string, args ...string) (*generator, plumbing.Hash) {
+	t.Helper()
+	gen, head, err := newTestGenerator(t, dir, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gen, head
+}
+
+// newTestGenerator is testGenerator for setups that are meant to fail.
+func newTestGenerator(t testing.TB, dir string, args ...string) (*generator, plumbing.Hash, error) {
+	t.Helper()
+	resetFlags()
+	silenceStderr(t)
+	if err := rootCmd.ParseFlags(append([]string{"--dir", dir, "--quiet"}, args...)); err != nil {
+		return nil, plumbing.ZeroHash, err
+	}
+	if err := loadConfig(); err != nil {
+		return nil, plumbing.ZeroHash, err
+	}
+	return newGenerator(rootCmd)
+}
+
+// walkReleases collects the releases a generator finds from head, the way
+// --all-tags lists them.
+func walkReleases(t testing.TB, gen *generator, head plumbing.Hash) []*Release {
+	t.Helper()
+	var releases []*Release
+	err := gen.walk(head, "", "", true, func(r *Release) error {
+		releases = append(releases, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return releases
+}
+
+// titles lists the titles of changes, to compare them in one go.
+func titles(changes []Change) []string {
+	list := make([]string, len(changes))
+	for i, change := range changes {
+		list[i] = change.Title
+	}
+	return list
+}
+
+// silenceStderr drops the warnings written to stderr for the rest of the
+// test, such as the one about a repo without a remote.
+func silenceStderr(t testing.TB) {
+	t.Helper()
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = devNull
+	t.Cleanup(func() {
+		os.Stderr = stderr
+		devNull.Close()
+	})
+}
+
+// pinNow makes now return date for the rest of the test.
+func pinNow(t testing.TB, date time.Time) {
+	t.Helper()
+	saved := now
+	now = func() time.Time { return date }
+	t.Cleanup(func() { now = saved })
+}
+
+// lines splits output into its non-empty lines.
+func lines(text string) []string {
+	var list []string
+	for _, line := range strings.Split(text, "\n") {
+		if line != "" {
+			list = append(list, line)
+		}
+	}
+	return list
+}
+
+// changeTitles extracts the change titles from a rendered markdown
+// release, dropping the version header and the trailing hash.
+func changeTitles(out string) []string {
+	var titles []string
+	for _, line := range lines(out) {
+		if !strings.HasPrefix(line, "- ") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "- ")
+		if i := strings.LastIndex(line, " ["); i >= 0 {
+			line = line[:i]
+		}
+		titles = append(titles, line)
+	}
+	return titles
+}
+
+// renderFormat renders release with the built-in template of format.
+func renderFormat(t testing.TB, format string, release *Release) string {
+	t.Helper()
+	f, err := lookupFormat(format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := parseTemplate(format, "text", f.template, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := render(tmpl, release)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+// replace replaces old in s with new, once.
+func replace(s, old, new string) string {
+	return strings.Replace(s, old, new, 1)
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// relativeDate describes a date in the YYYY-MM-DD form used by Release.Date
+// relative to now, such as "yesterday" or "3 weeks ago". Dates that don't
+// parse are returned as they are.
+func relativeDate(date string, now time.Time) string {
+	t, err := time.ParseInLocation("2006-01-02", date, now.Location())
+	if err != nil {
+		return date
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	// round, since days around a DST change aren't 24 hours long
+	days := int(math.Round(today.Sub(t).Hours() / 24))
+	switch {
+	case days < 0:
+		return date
+	case days == 0:
+		return "today"
+	case days == 1:
+		return "yesterday"
+	case days < 14:
+		return fmt.Sprintf("%d days ago", days)
+	case days < 60:
+		return fmt.Sprintf("%d weeks ago", days/7)
+	case days < 365*2:
+		return fmt.Sprintf("%d months ago", days/30)
+	}
+	return fmt.Sprintf("%d years ago", days/365)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRelativeDate(t *testing.T) {
+	now := time.Date(2024, 6, 15, 18, 30, 0, 0, time.UTC)
+	tests := []struct {
+		date string
+		want string
+	}{
+		{"2024-06-15", "today"},
+		{"2024-06-14", "yesterday"},
+		{"2024-06-12", "3 days ago"},
+		{"2024-06-02", "13 days ago"},
+		{"2024-06-01", "2 weeks ago"},
+		{"2024-04-17", "8 weeks ago"},
+		{"2024-04-16", "2 months ago"},
+		{"2022-06-17", "24 months ago"},
+		{"2022-06-15", "2 years ago"},
+		// in the future
+		{"2024-06-16", "2024-06-16"},
+		{"Unreleased", "Unreleased"},
+	}
+	for _, tt := range tests {
+		if got := relativeDate(tt.date, now); got != tt.want {
+			t.Errorf("relativeDate(%q) = %q, want %q", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestRelativeDatesAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	// clocks went forward on 2024-03-31
+	now := time.Date(2024, 4, 2, 0, 30, 0, 0, berlin)
+	if got := relativeDate("2024-03-30", now); got != "3 days ago" {
+		t.Errorf("got %q, want 3 days ago", got)
+	}
+}
+
+func TestRelativeDatesFlag(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a")
+	r.tag("v1.0.0")
+	pinNow(t, time.Date(2024, 1, 4, 9, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"absolute", nil, "2024-01-01"},
+		{"relative", []string{"--relative-dates"}, "3 days ago"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			release := walkReleases(t, gen, head)[0]
+			if release.Date != tt.want || release.Changes[0].Date != tt.want {
+				t.Errorf("release dated %q and change %q, want %q", release.Date, release.Changes[0].Date, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// templateFile is the starter template written by sumit init.
+const templateFile = ".sumit.tmpl"
+
+// starterConfig documents the most common settings. Everything but the
+// output format is commented out, so a fresh config changes nothing.
+const starterConfig = `# Defaults for sumit, read from the directory it runs in. Every setting is
+# the name of a command-line flag, which takes precedence when it's given.
+# Run sumit --help for the full list.
+
+# The format of the changelog.
+output-format: markdown
+
+# Render with the starter template next to this file instead of the
+# built-in one. It starts out the same as the markdown format.
+# template: .sumit.tmpl
+
+# Group changes under a heading per conventional type, or per type and
+# scope with type,scope.
+# group-by: type
+
+# Only list these conventional types.
+# include-types: [feat, fix, perf]
+
+# Leave out commits by bots such as Dependabot and Renovate.
+# exclude-bots: true
+
+# Only count tags with this prefix as releases, for monorepos.
+# tag-prefix: ""
+`
+
+// starterTemplate is the built-in markdown template, with a note on where
+// to learn about the fields it can use.
+const starterTemplate = `{{/*
+  A release template for sumit, rendered with Go's text/template once per
+  release. The "Templates" section of the README lists the fields and
+  functions available. Run sumit validate-template .sumit.tmpl after
+  editing it.
+*/ -}}
+` + releaseTemplate
+
+func init() {
+	initCmd.Flags().Bool("force", false, "Overwrite existing files")
+	rootCmd.AddCommand(initCmd)
+}
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a starter " + configFile + " config and " + templateFile + " template",
+	Args:  cobra.NoArgs,
+	// skip loading the config, so a broken one can be replaced with --force
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, _ := cmd.Flags().GetString("dir")
+		force, _ := cmd.Flags().GetBool("force")
+		files := []struct {
+			path, content string
+		}{
+			{filepath.Join(dir, configFile), starterConfig},
+			{filepath.Join(dir, templateFile), starterTemplate},
+		}
+
+		// check every file first so nothing is written when one exists
+		if !force {
+			for _, file := range files {
+				if _, err := os.Stat(file.path); err == nil {
+					bail(errors.New(fmt.Sprintf("%s already exists; pass --force to overwrite it", file.path)))
+				}
+			}
+		}
+		for _, file := range files {
+			bail(writeOutput(file.path, []byte(file.content)))
+			fmt.Printf("created %s\n", file.path)
+		}
+	},
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestInit(t *testing.T) {
+	dir := t.TempDir()
+	out := runSumit(t, dir, "init")
+	for _, name := range []string{configFile, templateFile} {
+		if !strings.Contains(out, "created "+filepath.Join(dir, name)) {
+			t.Errorf("output %q doesn't mention %s", out, name)
+		}
+	}
+
+	config, err := os.ReadFile(filepath.Join(dir, configFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var settings map[string]any
+	if err := yaml.Unmarshal(config, &settings); err != nil {
+		t.Fatal(err)
+	}
+	if len(settings) != 1 || settings["output-format"] != "markdown" {
+		t.Errorf("got settings %v, want only the output format", settings)
+	}
+	for _, want := range []string{"# template: .sumit.tmpl", "# group-by: type", "# exclude-bots: true"} {
+		if !strings.Contains(string(config), want) {
+			t.Errorf("config has no %q", want)
+		}
+	}
+
+	// the starter template renders like the built-in one
+	text, err := os.ReadFile(filepath.Join(dir, templateFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := parseTemplate("markdown", "text", string(text), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := render(tmpl, sampleRelease())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := renderFormat(t, "markdown", sampleRelease()); string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestInitExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, configFile)
+	if err := os.WriteFile(config, []byte("group-by: type\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := runSumitError(t, dir, "init")
+	if !strings.Contains(out, "pass --force to overwrite it") {
+		t.Errorf("got %q, want a --force hint", out)
+	}
+	if data, _ := os.ReadFile(config); string(data) != "group-by: type\n" {
+		t.Errorf("config was overwritten with %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, templateFile)); !os.IsNotExist(err) {
+		t.Errorf("template was written alongside an existing config: %v", err)
+	}
+
+	runSumit(t, dir, "init", "--force")
+	if data, _ := os.ReadFile(config); string(data) != starterConfig {
+		t.Errorf("config wasn't overwritten with --force:\n%s", data)
+	}
+}
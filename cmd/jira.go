@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+)
+
+// jiraKeyPattern matches issue keys such as PROJ-123: an upper-case project
+// key of at least two characters, a dash and a number without leading zeros,
+// standing on its own rather than inside a longer word or path.
+var jiraKeyPattern = regexp.MustCompile(`(?:^|[^\w/-])([A-Z][A-Z0-9]+-[1-9][0-9]*)\b`)
+
+// notJiraProjects are prefixes that look like issue keys but name standards
+// and algorithms, as in UTF-8 or SHA-256.
+var notJiraProjects = map[string]bool{
+	"CVE": true, "ISO": true, "MD": true, "RFC": true, "SHA": true, "UTF": true,
+}
+
+type Issue struct {
+	Key string `json:"key" yaml:"key"`
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+}
+
+// findIssues returns the distinct Jira issue keys mentioned in subject,
+// linked to the issue tracker at base.
+func findIssues(subject, base string) []Issue {
+	base = strings.TrimSuffix(base, "/")
+	var issues []Issue
+	seen := make(map[string]bool)
+	for _, m := range jiraKeyPattern.FindAllStringSubmatch(subject, -1) {
+		key := m[1]
+		project, _, _ := strings.Cut(key, "-")
+		if seen[key] || notJiraProjects[project] {
+			continue
+		}
+		seen[key] = true
+		issues = append(issues, Issue{Key: key, URL: base + "/browse/" + key})
+	}
+	return issues
+}
+
+// linkIssues replaces the issue keys in title with links to the issues,
+// using the output format's link syntax.
+func linkIssues(link func(string, string) string) func(string, []Issue) string {
+	return func(title string, issues []Issue) string {
+		for _, issue := range issues {
+			pattern := regexp.MustCompile(`(^|[^\w/-])` + regexp.QuoteMeta(issue.Key) + `\b`)
+			replacement := strings.ReplaceAll(link(issue.Key, issue.URL), "$", "$$")
+			title = pattern.ReplaceAllString(title, "${1}"+replacement)
+		}
+		return title
+	}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindIssues(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    []string
+	}{
+		{"[PROJ-123] fix login", []string{"PROJ-123"}},
+		{"fix: PROJ-1 and AB2-45, again PROJ-1", []string{"PROJ-1", "AB2-45"}},
+		{"bump SHA-256 and UTF-8 handling", nil},
+		{"fix: proj-123 is lower case", nil},
+		{"fix: A-1 has a one letter project", nil},
+		{"fix: PROJ-012 has a leading zero", nil},
+		{"fix: see docs/PROJ-12 and XPROJ-12X", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, issue := range findIssues(tt.subject, "https://jira.example.com/") {
+			if issue.URL != "https://jira.example.com/browse/"+issue.Key {
+				t.Errorf("%s links to %s", issue.Key, issue.URL)
+			}
+			got = append(got, issue.Key)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("findIssues(%q) = %q, want %q", tt.subject, got, tt.want)
+		}
+	}
+}
+
+func TestJiraBase(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("fix: [PROJ-123] handle empty input")
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"without flag", nil, "fix: [PROJ-123] handle empty input"},
+		{"with flag", []string{"--jira-base", "https://jira.example.com"}, "fix: [[PROJ-123](https://jira.example.com/browse/PROJ-123)] handle empty input"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := runSumit(t, r.dir, append(tt.args, "1.0.0")...)
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output has no %q:\n%s", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestLinkIssues(t *testing.T) {
+	issue := Issue{Key: "PROJ-1", URL: "https://jira.example.com/browse/PROJ-1"}
+	tests := []struct {
+		name   string
+		link   func(string, string) string
+		title  string
+		issues []Issue
+		want   string
+	}{
+		{"markdown", markdownLink, "fix: PROJ-1 crash", []Issue{issue}, "fix: [PROJ-1](https://jira.example.com/browse/PROJ-1) crash"},
+		{"slack", slackLink, "fix: PROJ-1 crash", []Issue{issue}, "fix: <https://jira.example.com/browse/PROJ-1|PROJ-1> crash"},
+		{"every mention", markdownLink, "PROJ-1: revert PROJ-1", []Issue{issue}, "[PROJ-1](https://jira.example.com/browse/PROJ-1): revert [PROJ-1](https://jira.example.com/browse/PROJ-1)"},
+		{"inside a longer key", markdownLink, "fix: XPROJ-1 and PROJ-10", []Issue{issue}, "fix: XPROJ-1 and PROJ-10"},
+		{"dollar in url", markdownLink, "fix: PROJ-1", []Issue{{Key: "PROJ-1", URL: "https://x.example.com/$1"}}, "fix: [PROJ-1](https://x.example.com/$1)"},
+		{"no issues", markdownLink, "fix: PROJ-1", nil, "fix: PROJ-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := linkIssues(tt.link)(tt.title, tt.issues); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ndjsonRenderer writes a release as newline-delimited JSON: one line with
+// the release's metadata, followed by one line per change.
+type ndjsonRenderer struct{}
+
+func (ndjsonRenderer) Execute(w io.Writer, data any) error {
+	release := *data.(*Release)
+	changes := release.Changes
+	release.Changes = nil
+	release.Groups = nil
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(release); err != nil {
+		return err
+	}
+	for _, change := range changes {
+		if err := enc.Encode(change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseLineRenderer writes a release, changes and all, as a single line
+// of JSON, so a backfill of every tag can be processed one release at a
+// time.
+type releaseLineRenderer struct{}
+
+func (releaseLineRenderer) Execute(w io.Writer, data any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(data)
+}
+
+// jsonRenderer writes a release as an indented JSON document. Without
+// --group-by it lists the release's changes under "changes"; with it, the
+// changes are only listed under the nested "groups", in the order the
+// markdown would show them.
+type jsonRenderer struct{}
+
+func (jsonRenderer) Execute(w io.Writer, data any) error {
+	release := *data.(*Release)
+	if len(release.Groups) > 0 {
+		release.Changes = nil
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(release)
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNDJSON(t *testing.T) {
+	release := sampleRelease()
+	var buf bytes.Buffer
+	if err := (ndjsonRenderer{}).Execute(&buf, release); err != nil {
+		t.Fatal(err)
+	}
+	got := lines(buf.String())
+	if len(got) != 1+len(release.Changes) {
+		t.Fatalf("got %d lines, want one for the release and one per change:\n%s", len(got), buf.String())
+	}
+
+	var meta map[string]any
+	if err := json.Unmarshal([]byte(got[0]), &meta); err != nil {
+		t.Fatalf("release line %q isn't JSON: %v", got[0], err)
+	}
+	if meta["version"] != "1.1.0" {
+		t.Errorf("got version %v, want 1.1.0", meta["version"])
+	}
+	for _, key := range []string{"changes", "groups"} {
+		if meta[key] != nil {
+			t.Errorf("release line has %s: %v", key, meta[key])
+		}
+	}
+	for i, line := range got[1:] {
+		var change Change
+		if err := json.Unmarshal([]byte(line), &change); err != nil {
+			t.Fatalf("change line %q isn't JSON: %v", line, err)
+		}
+		if !reflect.DeepEqual(change, release.Changes[i]) {
+			t.Errorf("line %d decodes to\n%+v\nwant\n%+v", i+2, change, release.Changes[i])
+		}
+	}
+	if len(release.Changes) == 0 {
+		t.Error("rendering emptied the release's changes")
+	}
+}
+
+func TestNDJSONOutput(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: <a> & b")
+	r.tag("v1.0.0")
+	r.commit("fix: c")
+
+	out := runSumit(t, r.dir, "--output-format", "ndjson", "--all-tags")
+	var versions []string
+	for _, line := range lines(out) {
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			t.Fatalf("line %q isn't JSON: %v", line, err)
+		}
+		if v, ok := obj["version"]; ok {
+			versions = append(versions, v.(string))
+		}
+	}
+	if want := []string{"Unreleased", "1.0.0"}; !reflect.DeepEqual(versions, want) {
+		t.Errorf("got releases %q, want %q", versions, want)
+	}
+	if !strings.Contains(out, `"feat: <a> & b"`) {
+		t.Errorf("output escapes HTML:\n%s", out)
+	}
+}
+
+func TestJSONLinesPerRelease(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a")
+	r.tag("v1.0.0")
+	r.commit("fix: b")
+	r.commit("feat: c")
+	r.tag("v1.1.0")
+	r.commit("fix: d")
+
+	out := runSumit(t, r.dir, "--output-format", "json-lines-per-release", "--all-tags")
+	var got [][]string
+	for _, line := range lines(out) {
+		var release Release
+		if err := json.Unmarshal([]byte(line), &release); err != nil {
+			t.Fatalf("line %q isn't a release: %v", line, err)
+		}
+		got = append(got, append([]string{release.Version}, titles(release.Changes)...))
+	}
+	want := [][]string{
+		{"Unreleased", "fix: d"},
+		{"1.1.0", "feat: c", "fix: b"},
+		{"1.0.0", "feat: a"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestGroupedJSON(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat(api): a")
+	r.commit("fix(api): b")
+	r.commit("feat(cli): c")
+
+	tests := []struct {
+		name    string
+		groupBy []string
+		want    string
+	}{
+		{
+			name: "flat",
+			want: `{"changes":["feat(cli): c","fix(api): b","feat(api): a"]}`,
+		},
+		{
+			name:    "by type",
+			groupBy: []string{"type"},
+			want:    `{"groups":[{"changes":["feat(cli): c","feat(api): a"],"title":"Features"},{"changes":["fix(api): b"],"title":"Bug Fixes"}]}`,
+		},
+		{
+			name:    "by type then scope",
+			groupBy: []string{"type", "scope"},
+			want:    `{"groups":[{"changes":["feat(cli): c","feat(api): a"],"groups":[{"changes":["feat(api): a"],"title":"api"},{"changes":["feat(cli): c"],"title":"cli"}],"title":"Features"},{"changes":["fix(api): b"],"groups":[{"changes":["fix(api): b"],"title":"api"}],"title":"Bug Fixes"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir)
+			gen.groupBy = tt.groupBy
+			var buf bytes.Buffer
+			if err := (jsonRenderer{}).Execute(&buf, walkReleases(t, gen, head)[0]); err != nil {
+				t.Fatal(err)
+			}
+			var release map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &release); err != nil {
+				t.Fatalf("output isn't JSON: %v\n%s", err, buf.String())
+			}
+			got, err := json.Marshal(outline(release))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// outline keeps the title, "changes" and "groups" of a decoded release or
+// group, with changes reduced to their titles and groups to their outlines.
+// Encoding it again sorts the keys.
+func outline(obj map[string]any) map[string]any {
+	kept := map[string]any{}
+	if title, ok := obj["title"]; ok {
+		kept["title"] = title
+	}
+	if changes, ok := obj["changes"].([]any); ok {
+		var list []any
+		for _, change := range changes {
+			list = append(list, change.(map[string]any)["title"])
+		}
+		kept["changes"] = list
+	}
+	if groups, ok := obj["groups"].([]any); ok {
+		var list []any
+		for _, group := range groups {
+			list = append(list, outline(group.(map[string]any)))
+		}
+		kept["groups"] = list
+	}
+	return kept
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"net/url"
+)
+
+// validBaseURL reports whether base can be used to build absolute links.
+func validBaseURL(base string) bool {
+	u, err := url.Parse(base)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// commitURL links to a commit on the remote, or returns an empty string when
+// there's nothing valid to link to so the change renders without a link.
+func commitURL(base, hash string) string {
+	if hash == "" || !validBaseURL(base) {
+		return ""
+	}
+	return base + "/commits/" + hash
+}
+
+// compareURL links to the diff between two refs on the remote, or returns
+// an empty string when either end is unknown.
+func compareURL(base, from, to string) string {
+	if from == "" || to == "" || !validBaseURL(base) {
+		return ""
+	}
+	return base + "/compare/" + from + "..." + to
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestCommitURL(t *testing.T) {
+	tests := []struct {
+		base, hash, want string
+	}{
+		{"https://github.com/acme/widget", "1a2b3c4", "https://github.com/acme/widget/commits/1a2b3c4"},
+		{"", "1a2b3c4", ""},
+		{"github.com/acme/widget", "1a2b3c4", ""},
+		{"https://github.com/acme/widget", "", ""},
+	}
+	for _, tt := range tests {
+		if got := commitURL(tt.base, tt.hash); got != tt.want {
+			t.Errorf("commitURL(%q, %q) = %q, want %q", tt.base, tt.hash, got, tt.want)
+		}
+	}
+}
+
+func TestCompareURL(t *testing.T) {
+	tests := []struct {
+		base, from, to, want string
+	}{
+		{"https://github.com/acme/widget", "v1.0.0", "v1.1.0", "https://github.com/acme/widget/compare/v1.0.0...v1.1.0"},
+		{"https://github.com/acme/widget", "", "v1.1.0", ""},
+		{"not a url", "v1.0.0", "v1.1.0", ""},
+	}
+	for _, tt := range tests {
+		if got := compareURL(tt.base, tt.from, tt.to); got != tt.want {
+			t.Errorf("compareURL(%q, %q, %q) = %q, want %q", tt.base, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestUnlinkedChangeRendersPlainSHA(t *testing.T) {
+	release := &Release{
+		Version: "1.1.0",
+		Date:    "2024-01-02",
+		Changes: []Change{
+			{SHA: "1a2b3c4", Title: "feat(api): add pagination (#42)", URL: commitURL("https://github.com/acme/widget", "1a2b3c4d5e6f")},
+			{SHA: "5d6e7f8", Title: "fix!: reject empty names", URL: commitURL("", "5d6e7f8")},
+		},
+	}
+	tmpl, err := parseTemplate("markdown", "text", releaseTemplate, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, release); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"[1a2b3c4](https://github.com/acme/widget/commits/1a2b3c4d5e6f)", "fix!: reject empty names [5d6e7f8]\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output has no %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "[5d6e7f8](") {
+		t.Errorf("output links the change without a URL:\n%s", out)
+	}
+}
+
+func TestCompareURLForExplicitRange(t *testing.T) {
+	r := newTestRepo(t)
+	r.remote("origin", "https://github.com/acme/widget.git")
+	r.commit("feat: a")
+	r.tag("v1.0.0")
+	r.commit("fix: b")
+	r.tag("v1.0.5")
+	r.commit("feat: c")
+	r.tag("v1.1.0")
+	r.commit("fix: d")
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"explicit range", []string{"--from", "v1.0.0", "--to", "v1.1.0", "1.1.0"}, "https://github.com/acme/widget/compare/v1.0.0...v1.1.0"},
+		{"explicit start", []string{"--from", "v1.0.0", "1.2.0"}, "https://github.com/acme/widget/compare/v1.0.0...v1.2.0"},
+		{"detected tag", []string{"1.2.0"}, "https://github.com/acme/widget/compare/v1.1.0...v1.2.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := runSumit(t, r.dir, append([]string{"--output-format", "ndjson"}, tt.args...)...)
+			if want := `"compare_url":"` + tt.want + `"`; !strings.Contains(out, want) {
+				t.Errorf("output has no %s:\n%s", want, out)
+			}
+		})
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		remote  string
+		baseURL string
+		want    string
+		wantErr bool
+	}{
+		{"remote", "git@git.internal:acme/widget.git", "", "https://git.internal/acme/widget/commits/", false},
+		{"override", "git@git.internal:acme/widget.git", "https://github.com/acme/widget/", "https://github.com/acme/widget/commits/", false},
+		{"no remote", "", "https://github.com/acme/widget", "https://github.com/acme/widget/commits/", false},
+		{"invalid", "", "github.com/acme/widget", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepo(t)
+			if tt.remote != "" {
+				r.remote("origin", tt.remote)
+			}
+			r.commit("feat: a")
+
+			var args []string
+			if tt.baseURL != "" {
+				args = []string{"--base-url", tt.baseURL}
+			}
+			gen, head, err := newTestGenerator(t, r.dir, args...)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "invalid base-url") {
+					t.Errorf("got %v, want an invalid base-url error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if url := walkReleases(t, gen, head)[0].Changes[0].URL; !strings.HasPrefix(url, tt.want) {
+				t.Errorf("got %q, want a link under %q", url, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareFromInitial(t *testing.T) {
+	r := newTestRepo(t)
+	r.remote("origin", "https://github.com/acme/widget.git")
+	root := r.commit("chore: start")
+	second := r.commit("feat: a")
+	r.tag("v1.0.0")
+	r.commit("fix: b")
+	const base = "https://github.com/acme/widget/compare/"
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"omitted by default", nil, []string{base + "v1.0.0...HEAD", ""}},
+		{"first commit", []string{"--compare-from-initial"}, []string{base + "v1.0.0...HEAD", base + root.String() + "...v1.0.0"}},
+		{"given ref", []string{"--compare-from-initial=" + second.String()[:7]}, []string{base + "v1.0.0...HEAD", base + second.String()[:7] + "...v1.0.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			var got []string
+			for _, release := range walkReleases(t, gen, head) {
+				got = append(got, release.CompareURL)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitialCommit(t *testing.T) {
+	r := newTestRepo(t)
+	root := r.commit("chore: start")
+	// another root, merged in as a second parent
+	if err := r.repo.Storer.RemoveReference(plumbing.Master); err != nil {
+		t.Fatal(err)
+	}
+	other := r.commit("chore: unrelated history")
+	r.reset(root)
+	r.commit("feat: a")
+	head := r.merge(other, "Merge the unrelated history")
+
+	got, err := initialCommit(r.repo, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != root {
+		t.Errorf("got %s, want the first-parent root %s", got, root)
+	}
+}
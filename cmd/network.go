@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/pkg/errors"
+)
+
+// retryDelay is how long to wait before the first retry of a network
+// operation. Every further retry waits twice as long as the one before.
+var retryDelay = time.Second
+
+// withRetry runs a network operation, giving each attempt at most timeout to
+// finish and retrying failed attempts up to retries times. Failures that
+// can't be fixed by trying again, like rejected credentials, are returned
+// right away.
+func withRetry(ctx context.Context, timeout time.Duration, retries int, op func(ctx context.Context) error) error {
+	delay := retryDelay
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := op(attemptCtx)
+		cancel()
+		if err == nil || isPermanent(err) || attempt >= retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func isPermanent(err error) bool {
+	if status, ok := errors.Cause(err).(*httpStatusError); ok {
+		// the server won't change its mind about a client error
+		return status.code < 500
+	}
+	switch errors.Cause(err) {
+	case git.NoErrAlreadyUpToDate,
+		git.ErrRemoteNotFound,
+		transport.ErrAuthenticationRequired,
+		transport.ErrAuthorizationFailed,
+		transport.ErrRepositoryNotFound,
+		context.Canceled:
+		return true
+	}
+	return false
+}
+
+// fetchTags fetches every tag from the remote, so the previous release can
+// be found in shallow clones that don't have tags locally.
+func fetchTags(repo *git.Repository, remote string, timeout time.Duration, retries int) error {
+	err := withRetry(context.Background(), timeout, retries, func(ctx context.Context) error {
+		return repo.FetchContext(ctx, &git.FetchOptions{
+			RemoteName: remote,
+			RefSpecs:   []config.RefSpec{"+refs/tags/*:refs/tags/*"},
+			Tags:       git.AllTags,
+		})
+	})
+	switch errors.Cause(err) {
+	case nil, git.NoErrAlreadyUpToDate:
+		return nil
+	case transport.ErrAuthenticationRequired, transport.ErrAuthorizationFailed:
+		return errors.Wrap(err, fmt.Sprintf("failed to fetch tags from %s; check the credentials for the remote", remote))
+	}
+	return errors.Wrap(err, fmt.Sprintf("failed to fetch tags from %s", remote))
+}
+
+// httpStatusError is a response other than 200 OK.
+type httpStatusError struct {
+	code int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("%d %s", e.code, http.StatusText(e.code))
+}
+
+// fetchURL downloads the body at url, which has to be served with 200 OK.
+func fetchURL(url string, timeout time.Duration, retries int) ([]byte, error) {
+	var body []byte
+	err := withRetry(context.Background(), timeout, retries, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return &httpStatusError{code: resp.StatusCode}
+		}
+		body, err = io.ReadAll(resp.Body)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to fetch %s", url))
+	}
+	return body, nil
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+func TestWithRetry(t *testing.T) {
+	delay := retryDelay
+	retryDelay = time.Millisecond
+	defer func() { retryDelay = delay }()
+	errFlaky := errors.New("connection reset")
+
+	tests := []struct {
+		name      string
+		retries   int
+		failures  int
+		err       error
+		wantCalls int
+		wantErr   error
+	}{
+		{"first try", 2, 0, errFlaky, 1, nil},
+		{"recovers", 2, 2, errFlaky, 3, nil},
+		{"gives up", 2, 5, errFlaky, 3, errFlaky},
+		{"no retries", 0, 5, errFlaky, 1, errFlaky},
+		{"permanent", 2, 5, transport.ErrAuthenticationRequired, 1, transport.ErrAuthenticationRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := withRetry(context.Background(), time.Second, tt.retries, func(ctx context.Context) error {
+				calls++
+				if calls <= tt.failures {
+					return tt.err
+				}
+				return nil
+			})
+			if err != tt.wantErr {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("got %d calls, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWithRetryTimeout(t *testing.T) {
+	delay := retryDelay
+	retryDelay = time.Millisecond
+	defer func() { retryDelay = delay }()
+
+	calls := 0
+	err := withRetry(context.Background(), 10*time.Millisecond, 1, func(ctx context.Context) error {
+		calls++
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != context.DeadlineExceeded || calls != 2 {
+		t.Errorf("got %v after %d calls, want a deadline error after 2", err, calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = withRetry(ctx, time.Second, 3, func(ctx context.Context) error {
+		return ctx.Err()
+	})
+	if err != context.Canceled {
+		t.Errorf("got %v, want the cancellation", err)
+	}
+}
+
+func TestFetchTags(t *testing.T) {
+	upstream := newTestRepo(t)
+	upstream.commit("feat: first")
+	upstream.tag("v1.0.0")
+	upstream.commit("feat: second")
+	bare := t.TempDir()
+	if _, err := git.PlainClone(bare, true, &git.CloneOptions{URL: upstream.dir}); err != nil {
+		t.Fatal(err)
+	}
+	// a CI checkout often comes without tags
+	repo, err := git.PlainClone(t.TempDir(), false, &git.CloneOptions{URL: bare, Tags: git.NoTags})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Tag("v1.0.0"); err != git.ErrTagNotFound {
+		t.Fatalf("got %v, want the clone to have no tags", err)
+	}
+
+	if err := fetchTags(repo, "origin", time.Second, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Tag("v1.0.0"); err != nil {
+		t.Errorf("got %v, want v1.0.0 to be fetched", err)
+	}
+	// fetching again has nothing new to bring in
+	if err := fetchTags(repo, "origin", time.Second, 0); err != nil {
+		t.Errorf("got %v fetching again, want no error", err)
+	}
+
+	err = fetchTags(repo, "missing", time.Second, 2)
+	if err == nil || !strings.Contains(err.Error(), "failed to fetch tags from missing") {
+		t.Errorf("got %v, want a fetch error for the missing remote", err)
+	}
+}
+
+func TestFetchURL(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/release.tmpl":
+			fmt.Fprint(w, "{{ .Version }}\n")
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{"ok", "/release.tmpl", "{{ .Version }}\n", ""},
+		{"not found", "/missing.tmpl", "", "404 Not Found"},
+		{"timeout", "/slow", "", "deadline exceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests.Store(0)
+			got, err := fetchURL(srv.URL+tt.path, 50*time.Millisecond, 0)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil || string(got) != tt.want {
+				t.Errorf("got %q, %v, want %q", got, err, tt.want)
+			}
+			if n := requests.Load(); n != 1 {
+				t.Errorf("made %d requests, want 1", n)
+			}
+		})
+	}
+}
+
+func TestTemplateFromURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "Release {{ .Version }}{{ range .Changes }}\n* {{ .Title }}{{ end }}\n")
+	}))
+	defer srv.Close()
+
+	r := newTestRepo(t)
+	r.commit("feat: a")
+
+	out := runSumit(t, r.dir, "--template", srv.URL+"/release.tmpl", "1.0.0")
+	if want := "Release 1.0.0\n* feat: a\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
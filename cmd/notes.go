@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	noteHeadingPattern = regexp.MustCompile(`^#{1,6}\s+(.+?)\s*#*$`)
+	noteBulletPattern  = regexp.MustCompile(`^[-*+]\s+(.+)$`)
+	// githubNotePattern matches the entries of GitHub's generated release
+	// notes, "feat: add x by @octocat in https://github.com/o/r/pull/12".
+	githubNotePattern = regexp.MustCompile(`^(.+?) by @(\S+) in https?://\S+/pull/(\d+)$`)
+)
+
+// ignoredNoteSections are headings of GitHub's release notes whose bullets
+// aren't changes.
+var ignoredNoteSections = map[string]bool{
+	"new contributors": true,
+}
+
+// readNotes parses the top-level bullets of a markdown file of release notes
+// into changes, for --merge-notes. A bullet under a heading such as
+// "Features" or "Bug Fixes" gets the matching conventional type, one under
+// "Breaking Changes" is breaking, and otherwise a conventional title is
+// parsed like a commit's.
+func readNotes(path string) ([]Change, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	titleTypes := make(map[string]string, len(typeTitles))
+	for t, title := range typeTitles {
+		titleTypes[strings.ToLower(title)] = t
+	}
+
+	var changes []Change
+	var section string
+	for _, line := range strings.Split(strings.ReplaceAll(string(text), "\r\n", "\n"), "\n") {
+		if m := noteHeadingPattern.FindStringSubmatch(line); m != nil {
+			section = strings.ToLower(m[1])
+			continue
+		}
+		m := noteBulletPattern.FindStringSubmatch(line)
+		if m == nil || ignoredNoteSections[section] {
+			continue
+		}
+
+		change := Change{Title: strings.TrimSpace(m[1]), Fragment: true}
+		if gh := githubNotePattern.FindStringSubmatch(change.Title); gh != nil {
+			change.Title = gh[1]
+			change.Author = gh[2]
+			change.PR, _ = strconv.Atoi(gh[3])
+		} else {
+			change.PR = prNumber(change.Title)
+		}
+		if cc, ok := parseConventional(change.Title); ok {
+			change.Type = cc.Type
+			change.Scope = cc.Scope
+			change.Breaking = cc.Breaking
+			change.Conventional = true
+		}
+		if t, ok := titleTypes[section]; ok {
+			change.Type = t
+		} else if section == strings.ToLower(breakingGroupTitle) {
+			change.Breaking = true
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
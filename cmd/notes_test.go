@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const githubNotes = `## What's Changed
+### Features
+* Add search by @octocat in https://github.com/acme/widget/pull/12
+### Breaking Changes
+- fix(api)!: drop v1 by @hubot in https://github.com/acme/widget/pull/13
+## Other
+* docs: reword the guide (#14)
+  * nested bullets aren't entries
+## New Contributors
+* @newbie made their first contribution in https://github.com/acme/widget/pull/12
+`
+
+func TestReadNotes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "NOTES.md")
+	if err := os.WriteFile(path, []byte(githubNotes), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readNotes(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Title: "Add search", Author: "octocat", PR: 12, Type: "feat", Fragment: true},
+		{Title: "fix(api)!: drop v1", Author: "hubot", PR: 13, Type: "fix", Scope: "api", Breaking: true, Conventional: true, Fragment: true},
+		{Title: "docs: reword the guide (#14)", PR: 14, Type: "docs", Conventional: true, Fragment: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestMergeNotes(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: add search (#12)")
+	r.commit("Docs: Reword  the guide (#14)")
+	r.commit("fix: unrelated")
+	path := filepath.Join(t.TempDir(), "NOTES.md")
+	if err := os.WriteFile(path, []byte(githubNotes), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gen, head := testGenerator(t, r.dir, "--merge-notes", path)
+	want := []string{"Add search", "fix(api)!: drop v1", "docs: reword the guide (#14)", "fix: unrelated"}
+	if got := titles(walkReleases(t, gen, head)[0].Changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const changelogTitle = "# Changelog\n"
+
+type insertMode int
+
+const (
+	insertPrepend insertMode = iota
+	insertAppend
+)
+
+// writeOutput replaces the contents of path with the rendered section.
+func writeOutput(path string, section []byte) error {
+	if err := os.WriteFile(path, section, 0o644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+	return nil
+}
+
+// insertSection adds a rendered release section to an existing changelog,
+// either before the newest release (prepend) or after the oldest (append).
+// A missing changelog is created with a title and the section.
+func insertSection(path string, section []byte, mode insertMode) error {
+	existing, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		existing = []byte(changelogTitle)
+	} else if err != nil {
+		return errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	section = bytes.TrimRight(section, "\n")
+	var buf bytes.Buffer
+	switch mode {
+	case insertPrepend:
+		head, tail := splitAtFirstRelease(string(existing))
+		buf.WriteString(strings.TrimRight(head, "\n"))
+		buf.WriteString("\n\n")
+		buf.Write(section)
+		buf.WriteString("\n")
+		if tail != "" {
+			buf.WriteString("\n")
+			buf.WriteString(tail)
+		}
+	case insertAppend:
+		buf.WriteString(strings.TrimRight(string(existing), "\n"))
+		buf.WriteString("\n\n")
+		buf.Write(section)
+		buf.WriteString("\n")
+	}
+	return writeOutput(path, buf.Bytes())
+}
+
+// splitAtFirstRelease splits a changelog right before its first release
+// heading, separating the title and preamble from the release sections.
+func splitAtFirstRelease(changelog string) (string, string) {
+	offset := 0
+	for _, line := range strings.SplitAfter(changelog, "\n") {
+		if strings.HasPrefix(line, "## ") {
+			return changelog[:offset], changelog[offset:]
+		}
+		offset += len(line)
+	}
+	return changelog, ""
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// writeSplitOutput renders each group of the release as its own section and
+// writes it to a file named after the group inside dir, with the extension
+// ext.
+func writeSplitOutput(dir, ext string, tmpl renderer, release *Release) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return errors.Wrapf(err, "failed to create %s", dir)
+	}
+
+	used := make(map[string]bool)
+	for _, group := range release.Groups {
+		section := *release
+		section.Changes = group.Changes
+		section.Groups = group.Groups
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, &section); err != nil {
+			return err
+		}
+
+		// numbered names can clash with other groups, as with a second
+		// "a" group next to one titled "a-2"
+		base := groupFilename(group.Title)
+		name := base
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[name] = true
+		if err := writeOutput(filepath.Join(dir, name+ext), buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// groupFilename turns a group title into a safe file name: lower-cased,
+// with anything but letters, digits, dots, dashes and underscores replaced
+// by dashes.
+func groupFilename(title string) string {
+	name := unsafeFilenameChars.ReplaceAllString(strings.ToLower(title), "-")
+	name = strings.Trim(name, "-.")
+	if name == "" {
+		return "group"
+	}
+	return name
+}
@@ -0,0 +1,316 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestInsertSection(t *testing.T) {
+	const existing = "# Changelog\n\nAll notable changes.\n\n## [0.2.0] - 2024-02-01\n\n- second\n\n## [0.1.0] - 2024-01-01\n\n- first\n"
+	const section = "## [0.3.0] - 2024-03-01\n\n- third\n"
+
+	tests := []struct {
+		name     string
+		existing string
+		mode     insertMode
+		want     string
+	}{
+		{
+			name:     "prepend",
+			existing: existing,
+			mode:     insertPrepend,
+			want:     "# Changelog\n\nAll notable changes.\n\n## [0.3.0] - 2024-03-01\n\n- third\n\n## [0.2.0] - 2024-02-01\n\n- second\n\n## [0.1.0] - 2024-01-01\n\n- first\n",
+		},
+		{
+			name:     "append",
+			existing: existing,
+			mode:     insertAppend,
+			want:     existing + "\n" + section,
+		},
+		{
+			name:     "prepend to a file without releases",
+			existing: "# Changelog\n",
+			mode:     insertPrepend,
+			want:     "# Changelog\n\n" + section,
+		},
+		{
+			name: "prepend to a missing file",
+			mode: insertPrepend,
+			want: "# Changelog\n\n" + section,
+		},
+		{
+			name: "append to a missing file",
+			mode: insertAppend,
+			want: "# Changelog\n\n" + section,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := insertSection(path, []byte(section+"\n\n"), tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupFilename(t *testing.T) {
+	tests := []struct {
+		title, want string
+	}{
+		{"Features", "features"},
+		{"Bug Fixes", "bug-fixes"},
+		{"Jane Doe <jane@example.com>", "jane-doe-jane-example.com"},
+		{"../../etc/passwd", "etc-passwd"},
+		{"v1.2_beta", "v1.2_beta"},
+		{"***", "group"},
+	}
+	for _, tt := range tests {
+		if got := groupFilename(tt.title); got != tt.want {
+			t.Errorf("groupFilename(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestWriteSplitOutputNames(t *testing.T) {
+	tmpl := template.Must(template.New("release").Parse("{{ range .Changes }}{{ .Title }}\n{{ end }}"))
+	release := &Release{Groups: []Group{
+		{Title: "a", Changes: []Change{{Title: "first a"}}},
+		{Title: "A", Changes: []Change{{Title: "second a"}}},
+		{Title: "a-2", Changes: []Change{{Title: "a-2"}}},
+		{Title: "a", Changes: []Change{{Title: "third a"}}},
+	}}
+	dir := t.TempDir()
+	if err := writeSplitOutput(dir, ".txt", tmpl, release); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"a.txt":     "first a\n",
+		"a-2.txt":   "second a\n",
+		"a-2-2.txt": "a-2\n",
+		"a-3.txt":   "third a\n",
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(want) {
+		t.Errorf("got %d files, want %d", len(entries), len(want))
+	}
+	for name, content := range want {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(got) != content {
+			t.Errorf("%s = %q, want %q", name, got, content)
+		}
+	}
+}
+
+func TestSplitOutput(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: add search")
+	r.commit("fix: handle empty input")
+	r.commit("feat: add paging")
+
+	dir := filepath.Join(t.TempDir(), "notes")
+	runSumit(t, r.dir, "--group-by", "type", "--split-output", dir, "1.0.0")
+	for name, want := range map[string][]string{
+		"features.md":  {"feat: add paging", "feat: add search"},
+		"bug-fixes.md": {"fix: handle empty input"},
+	} {
+		out, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if got := changeTitles(string(out)); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s has %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestSplitExtension(t *testing.T) {
+	tests := []struct {
+		format, want string
+	}{
+		{"markdown", ".md"},
+		{"markdown-refs", ".md"},
+		{"markdown-table", ".md"},
+		{"github-release", ".md"},
+		{"gitlab-release", ".md"},
+		{"discord", ".md"},
+		{"slack", ".txt"},
+		{"confluence-wiki", ".txt"},
+		{"html", ".html"},
+		{"json", ".json"},
+		{"email", ".eml"},
+		{"ndjson", ".ndjson"},
+		{"json-lines-per-release", ".ndjson"},
+		{"csv", ".csv"},
+		{"yaml", ".yaml"},
+	}
+	for _, tt := range tests {
+		f, err := lookupFormat(tt.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.extension != tt.want {
+			t.Errorf("%s has extension %q, want %q", tt.format, f.extension, tt.want)
+		}
+	}
+}
+
+func TestUnreleasedOutput(t *testing.T) {
+	pinNow(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	today := "2024-06-01"
+	tests := []struct {
+		name           string
+		unreleased     []string
+		wantUnreleased []string
+	}{
+		{"unreleased commits", []string{"fix: b", "feat: c"}, []string{"## [Unreleased] - " + today, "- feat: c", "- fix: b"}},
+		{"tagged head", nil, []string{"## [Unreleased] - " + today, "- No notable changes"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepo(t)
+			r.commit("feat: a")
+			r.tag("v1.0.0")
+			for _, msg := range tt.unreleased {
+				r.commit(msg)
+			}
+			unreleasedPath := filepath.Join(t.TempDir(), "UNRELEASED.md")
+			releasesPath := filepath.Join(t.TempDir(), "RELEASE.md")
+			runSumit(t, r.dir, "--unreleased-output", unreleasedPath, "--output", releasesPath)
+
+			assertLines(t, unreleasedPath, tt.wantUnreleased)
+			assertLines(t, releasesPath, []string{"## [1.0.0] - 2024-01-01", "- feat: a"})
+		})
+	}
+}
+
+// assertLines checks that the file at path has each of want as a line,
+// with the commit links dropped, and nothing else.
+func assertLines(t *testing.T, path string, want []string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, line := range lines(string(data)) {
+		if i := strings.Index(line, " ["); i > 0 && strings.HasPrefix(line, "- ") {
+			line = line[:i]
+		}
+		got = append(got, line)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s has\n%q\nwant\n%q", filepath.Base(path), got, want)
+	}
+}
+
+func TestTee(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a")
+	r.tag("v1.0.0")
+	r.commit("fix: b")
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"single release", []string{"1.1.0"}},
+		{"all tags", []string{"--all-tags"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+			args := append([]string{"--output", path}, tt.args...)
+			if out := runSumit(t, r.dir, args...); out != "" {
+				t.Errorf("got stdout %q without --tee, want none", out)
+			}
+			out := runSumit(t, r.dir, append(args, "--tee")...)
+			written, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out == "" || out != string(written) {
+				t.Errorf("stdout\n%s\ndiffers from the file\n%s", out, written)
+			}
+		})
+	}
+}
+
+func TestWithUnreleased(t *testing.T) {
+	pinNow(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	today := "2024-06-01"
+	tests := []struct {
+		name    string
+		commits []string
+		args    []string
+		want    []string
+	}{
+		{
+			name:    "commits after the tag",
+			commits: []string{"fix: after the tag"},
+			want:    []string{"## [Unreleased] - " + today, "- fix: after the tag", "## [1.1.0] - 2024-01-01", "- feat: b"},
+		},
+		{
+			name: "tagged head",
+			want: []string{"## [1.1.0] - 2024-01-01", "- feat: b"},
+		},
+		{
+			name:    "older version",
+			commits: []string{"fix: after the tag"},
+			args:    []string{"1.0.0"},
+			want:    []string{"## [Unreleased] - " + today, "- fix: after the tag", "## [1.1.0] - 2024-01-01", "- feat: b", "## [1.0.0] - 2024-01-01", "- feat: a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepo(t)
+			r.commit("feat: a")
+			r.tag("v1.0.0")
+			r.commit("feat: b")
+			r.tag("v1.1.0")
+			for _, msg := range tt.commits {
+				r.commit(msg)
+			}
+			path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+			runSumit(t, r.dir, append([]string{"--with-unreleased", "--output", path}, tt.args...)...)
+			assertLines(t, path, tt.want)
+		})
+	}
+}
+
+func TestWithUnreleasedUnknownVersion(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a")
+	r.tag("v1.0.0")
+	r.commit("fix: b")
+
+	if out := runSumitError(t, r.dir, "--with-unreleased", "0.9.0"); !strings.Contains(out, "no release tagged v0.9.0") {
+		t.Errorf("got %q, want an unknown release error", out)
+	}
+}
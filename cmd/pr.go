@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// squashPRPattern matches the "(#42)" suffix GitHub adds to squash and
+	// merge subjects.
+	squashPRPattern = regexp.MustCompile(`\(#(\d+)\)\s*$`)
+	prRefPattern    = regexp.MustCompile(`(?:^|[^\w&])#(\d+)\b`)
+)
+
+// prNumber finds the pull request a subject refers to, preferring the
+// trailing "(#42)" form over a reference anywhere else. It returns 0 when
+// there's none.
+func prNumber(subject string) int {
+	m := squashPRPattern.FindStringSubmatch(subject)
+	if m == nil {
+		m = prRefPattern.FindStringSubmatch(subject)
+	}
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// squashMerge is a commit created by GitHub's "squash and merge", whose
+// body lists the subjects of the squashed commits as bullets.
+type squashMerge struct {
+	Title   string
+	PR      int
+	Commits []string
+}
+
+// parseSquashMerge recognizes a squash-merge message by its "(#42)" subject
+// suffix and bulleted body.
+func parseSquashMerge(message string) (squashMerge, bool) {
+	lines := strings.Split(strings.TrimSpace(message), "\n")
+	m := squashPRPattern.FindStringSubmatchIndex(lines[0])
+	if m == nil {
+		return squashMerge{}, false
+	}
+
+	var commits []string
+	for _, line := range lines[1:] {
+		if strings.HasPrefix(line, "* ") {
+			commits = append(commits, strings.TrimSpace(strings.TrimPrefix(line, "* ")))
+		}
+	}
+	if len(commits) == 0 {
+		return squashMerge{}, false
+	}
+
+	pr, _ := strconv.Atoi(lines[0][m[2]:m[3]])
+	return squashMerge{
+		Title:   strings.TrimSpace(lines[0][:m[0]]),
+		PR:      pr,
+		Commits: commits,
+	}, true
+}
+
+// collapsePRs merges the changes that refer to the same pull request into
+// one, where the newest of them was. It's titled by the PR's merge commit
+// when that's a conventional commit, and by the PR's first commit
+// otherwise. With listCommits, the subjects of the PR's other commits are
+// nested under it, oldest first.
+func collapsePRs(changes []Change, listCommits bool) []Change {
+	byPR := make(map[int][]Change)
+	for _, change := range changes {
+		if change.PR != 0 {
+			byPR[change.PR] = append(byPR[change.PR], change)
+		}
+	}
+
+	var collapsed []Change
+	done := make(map[int]bool)
+	for _, change := range changes {
+		group := byPR[change.PR]
+		if change.PR == 0 || len(group) == 1 {
+			collapsed = append(collapsed, change)
+			continue
+		}
+		if done[change.PR] {
+			continue
+		}
+		done[change.PR] = true
+
+		// the walk lists commits newest first
+		entry := group[len(group)-1]
+		for _, c := range group {
+			if c.Merge && c.Conventional {
+				entry = c
+			}
+		}
+		var commits []string
+		for i := len(group) - 1; i >= 0; i-- {
+			if group[i].Breaking {
+				entry.Breaking = true
+			}
+			if group[i].SHA != entry.SHA && !group[i].Merge {
+				commits = append(commits, group[i].Title)
+			}
+		}
+		if listCommits {
+			entry.Commits = append(entry.Commits, commits...)
+		}
+		collapsed = append(collapsed, entry)
+	}
+	return collapsed
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const squashMessage = `Add retries to the uploader (#42)
+
+* feat: retry failed uploads
+* fix: back off between retries
+
+Co-authored-by: Bob <bob@example.com>`
+
+func TestParseSquashMerge(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    squashMerge
+		ok      bool
+	}{
+		{"squash merge", squashMessage, squashMerge{
+			Title:   "Add retries to the uploader",
+			PR:      42,
+			Commits: []string{"feat: retry failed uploads", "fix: back off between retries"},
+		}, true},
+		{"no bullets", "Add retries (#42)\n\nJust a body.", squashMerge{}, false},
+		{"no pull request", "Add retries\n\n* feat: retry", squashMerge{}, false},
+		{"reference mid-subject", "Fix #42 in uploader\n\n* fix: it", squashMerge{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseSquashMerge(tt.message)
+			if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, %v, want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSquashMergeWithBody(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit(squashMessage)
+	tmpl := filepath.Join(t.TempDir(), "change.tmpl")
+	err := os.WriteFile(tmpl, []byte("{{ range .Changes }}{{ .Title }} #{{ .PR }}\n{{ range .Commits }}{{ . }}\n{{ end }}{{ .Body }}{{ end }}"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"without body", nil, []string{"Add retries to the uploader #42"}},
+		{"with body", []string{"--with-body"}, []string{"Add retries to the uploader #42", "feat: retry failed uploads", "fix: back off between retries"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append(tt.args, "--template", tmpl, "1.0.0")
+			if got := lines(runSumit(t, r.dir, args...)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPRNumber(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    int
+	}{
+		{"feat: add search (#42)", 42},
+		{"Merge pull request #17 from acme/topic", 17},
+		{"fix: #3 then (#4)", 4},
+		{"fix: issue#5 isn't a reference", 0},
+		{"fix: &#39; is an entity", 0},
+		{"chore: direct to main", 0},
+	}
+	for _, tt := range tests {
+		if got := prNumber(tt.subject); got != tt.want {
+			t.Errorf("prNumber(%q) = %d, want %d", tt.subject, got, tt.want)
+		}
+	}
+}
+
+func TestPRsOnly(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: add search (#12)")
+	r.commit("chore: direct to main")
+	r.commit("Merge pull request #13 from acme/topic")
+	r.commit(squashMessage)
+	r.writeFile(".changelog.d/entry.md", "Rewrote the guide\n")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"all", nil, []string{"Rewrote the guide", "Add retries to the uploader", "Merge pull request #13 from acme/topic", "chore: direct to main", "feat: add search (#12)"}},
+		{"prs only", []string{"--prs-only"}, []string{"Rewrote the guide", "Add retries to the uploader", "Merge pull request #13 from acme/topic", "feat: add search (#12)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			if got := titles(walkReleases(t, gen, head)[0].Changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollapsePRs(t *testing.T) {
+	tests := []struct {
+		name        string
+		changes     []Change
+		listCommits bool
+		want        []Change
+	}{
+		{
+			name: "titled by the first commit, where the newest was",
+			changes: []Change{
+				{SHA: "c3", Title: "fix: typo (#7)", PR: 7},
+				{SHA: "c2", Title: "feat: other"},
+				{SHA: "c1", Title: "feat: add x (#7)", PR: 7},
+			},
+			want: []Change{
+				{SHA: "c1", Title: "feat: add x (#7)", PR: 7},
+				{SHA: "c2", Title: "feat: other"},
+			},
+		},
+		{
+			name: "listing the other commits oldest first",
+			changes: []Change{
+				{SHA: "c3", Title: "test: cover x (#7)", PR: 7},
+				{SHA: "c2", Title: "fix: typo (#7)", PR: 7},
+				{SHA: "c1", Title: "feat: add x (#7)", PR: 7},
+			},
+			listCommits: true,
+			want: []Change{
+				{SHA: "c1", Title: "feat: add x (#7)", PR: 7, Commits: []string{"fix: typo (#7)", "test: cover x (#7)"}},
+			},
+		},
+		{
+			name: "titled by a conventional merge commit",
+			changes: []Change{
+				{SHA: "m", Title: "feat: add x (#8)", PR: 8, Merge: true, Conventional: true},
+				{SHA: "c2", Title: "wip (#8)", PR: 8},
+				{SHA: "c1", Title: "start (#8)", PR: 8},
+			},
+			listCommits: true,
+			want: []Change{
+				{SHA: "m", Title: "feat: add x (#8)", PR: 8, Merge: true, Conventional: true, Commits: []string{"start (#8)", "wip (#8)"}},
+			},
+		},
+		{
+			name: "not by any other merge commit",
+			changes: []Change{
+				{SHA: "m", Title: "Merge pull request #9 from acme/x", PR: 9, Merge: true},
+				{SHA: "c1", Title: "feat: add x (#9)", PR: 9, Conventional: true},
+			},
+			listCommits: true,
+			want: []Change{
+				{SHA: "c1", Title: "feat: add x (#9)", PR: 9, Conventional: true},
+			},
+		},
+		{
+			name: "breaking when any commit is",
+			changes: []Change{
+				{SHA: "c2", Title: "fix!: drop y (#7)", PR: 7, Breaking: true},
+				{SHA: "c1", Title: "feat: add x (#7)", PR: 7},
+			},
+			want: []Change{
+				{SHA: "c1", Title: "feat: add x (#7)", PR: 7, Breaking: true},
+			},
+		},
+		{
+			name: "single commits untouched",
+			changes: []Change{
+				{SHA: "c2", Title: "fix: b (#2)", PR: 2},
+				{SHA: "c1", Title: "feat: a (#1)", PR: 1},
+			},
+			listCommits: true,
+			want: []Change{
+				{SHA: "c2", Title: "fix: b (#2)", PR: 2},
+				{SHA: "c1", Title: "feat: a (#1)", PR: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := collapsePRs(tt.changes, tt.listCommits); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollapsePRsFlag(t *testing.T) {
+	r := newTestRepo(t)
+	base := r.commit("chore: base")
+	r.commit("feat: start x (#7)")
+	branch := r.commit("fix: finish x (#7)")
+	r.reset(base)
+	r.commit("docs: unrelated")
+	r.merge(branch, "feat: add x (#7)")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"off", nil, []string{"feat: add x (#7)", "docs: unrelated", "chore: base", "fix: finish x (#7)", "feat: start x (#7)"}},
+		{"on", []string{"--collapse-prs"}, []string{"feat: add x (#7)", "docs: unrelated", "chore: base"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			if got := titles(walkReleases(t, gen, head)[0].Changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	gen, head := testGenerator(t, r.dir, "--collapse-prs", "--with-body")
+	entry := walkReleases(t, gen, head)[0].Changes[0]
+	if want := []string{"feat: start x (#7)", "fix: finish x (#7)"}; !reflect.DeepEqual(entry.Commits, want) {
+		t.Errorf("got commits %q, want %q", entry.Commits, want)
+	}
+}
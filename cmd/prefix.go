@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// parseStripPrefixes turns --strip-prefix values into patterns anchored at
+// the start of a subject. Values between slashes, such as /[A-Z]+-\d+:/,
+// are regular expressions, and others are matched literally.
+func parseStripPrefixes(values []string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, value := range values {
+		expr := regexp.QuoteMeta(value)
+		if len(value) > 2 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") {
+			expr = value[1 : len(value)-1]
+		}
+		re, err := regexp.Compile(`^(?:` + expr + `)`)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("invalid strip-prefix %s", value))
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// stripPrefixes removes the prefixes matching patterns from the start of a
+// subject, in order, along with the spaces after each. A prefix that's the
+// whole subject is kept, so there's something left to list.
+func stripPrefixes(subject string, patterns []*regexp.Regexp) string {
+	for _, re := range patterns {
+		if loc := re.FindStringIndex(subject); loc != nil && loc[1] > 0 {
+			if rest := strings.TrimLeft(subject[loc[1]:], " \t"); rest != "" {
+				subject = rest
+			}
+		}
+	}
+	return subject
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStripPrefixes(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefixes []string
+		subject  string
+		want     string
+	}{
+		{"literal", []string{"[core]"}, "[core] feat: a", "feat: a"},
+		{"literal without the prefix", []string{"[core]"}, "feat: a [core]", "feat: a [core]"},
+		{"literal metacharacters", []string{"a.b"}, "axb feat: a", "axb feat: a"},
+		{"regex", []string{`/[A-Z]+-\d+:/`}, "API-12: fix nil", "fix nil"},
+		{"regex without the prefix", []string{`/[A-Z]+-\d+:/`}, "fix API-12: nil", "fix API-12: nil"},
+		{"regex anchored at the start", []string{`/core|web/`}, "fix web", "fix web"},
+		{"in order", []string{"[core]", `/[A-Z]+-\d+:/`}, "[core] API-12: fix nil", "fix nil"},
+		{"not in reverse order", []string{"[core]", `/[A-Z]+-\d+:/`}, "API-12: [core] fix nil", "[core] fix nil"},
+		{"whole subject kept", []string{"[core]"}, "[core]", "[core]"},
+		{"empty match ignored", []string{"/x*/"}, "feat: a", "feat: a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patterns, err := parseStripPrefixes(tt.prefixes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := stripPrefixes(tt.subject, patterns); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStripPrefixesRejectsBadRegex(t *testing.T) {
+	_, err := parseStripPrefixes([]string{"/[/"})
+	if err == nil || !strings.Contains(err.Error(), "invalid strip-prefix /[/") {
+		t.Errorf("got %v, want an invalid strip-prefix error", err)
+	}
+}
+
+func TestStripPrefixFlag(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("[core] feat(api): a")
+	r.commit("OPS-3: fix: b")
+	r.commit("docs: c")
+
+	gen, head := testGenerator(t, r.dir, "--strip-prefix", "[core]", "--strip-prefix", `/[A-Z]+-\d+:/`)
+	changes := walkReleases(t, gen, head)[0].Changes
+	if want := []string{"docs: c", "fix: b", "feat(api): a"}; !reflect.DeepEqual(titles(changes), want) {
+		t.Errorf("got %q, want %q", titles(changes), want)
+	}
+	// stripped before the subject is parsed
+	if c := changes[2]; c.Type != "feat" || c.Scope != "api" {
+		t.Errorf("got type %q and scope %q, want feat and api", c.Type, c.Scope)
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// linkRemote picks the remote that links point to: the upstream remote of
+// the checked out branch (branch.<name>.remote), then origin, then the first
+// remote by name. It returns an empty string when there are no remotes.
+func linkRemote(repo *git.Repository, head *plumbing.Reference) string {
+	cfg, err := repo.Config()
+	if err != nil {
+		return ""
+	}
+	if head.Name().IsBranch() {
+		if branch, ok := cfg.Branches[head.Name().Short()]; ok && branch.Remote != "" {
+			if _, ok := cfg.Remotes[branch.Remote]; ok {
+				return branch.Remote
+			}
+		}
+	}
+	if _, ok := cfg.Remotes["origin"]; ok {
+		return "origin"
+	}
+	names := make([]string, 0, len(cfg.Remotes))
+	for name := range cfg.Remotes {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+// preferredRemote picks the remote that links point to with
+// --remote-priority: the first of names that exists and whose URL can be
+// turned into a web address. When none of them can, it falls back to the
+// remote linkRemote picks, and then to the other remotes by name, again
+// skipping those that can't be linked to.
+func preferredRemote(repo *git.Repository, head *plumbing.Reference, names []string) string {
+	fallback := linkRemote(repo, head)
+	cfg, err := repo.Config()
+	if err != nil {
+		return fallback
+	}
+	candidates := append(append([]string{}, names...), fallback)
+	others := make([]string, 0, len(cfg.Remotes))
+	for name := range cfg.Remotes {
+		others = append(others, name)
+	}
+	sort.Strings(others)
+	candidates = append(candidates, others...)
+
+	for _, name := range candidates {
+		if rem, ok := cfg.Remotes[name]; ok && len(rem.URLs) > 0 {
+			if _, err := parseRemoteURL(rem.URLs[0]); err == nil {
+				return name
+			}
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestLinkRemote(t *testing.T) {
+	tests := []struct {
+		name     string
+		remotes  []string
+		upstream string
+		want     string
+	}{
+		{"no remotes", nil, "", ""},
+		{"origin", []string{"fork", "origin"}, "", "origin"},
+		{"first by name", []string{"upstream", "fork"}, "", "fork"},
+		{"branch upstream", []string{"origin", "upstream"}, "upstream", "upstream"},
+		{"missing upstream", []string{"origin"}, "gone", "origin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepo(t)
+			r.commit("feat: first")
+			for _, name := range tt.remotes {
+				r.remote(name, "https://github.com/"+name+"/widget.git")
+			}
+			if tt.upstream != "" {
+				setUpstream(t, r, "master", tt.upstream)
+			}
+			head, err := r.repo.Head()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := linkRemote(r.repo, head); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// setUpstream sets branch.<branch>.remote in the repo's config.
+func setUpstream(t *testing.T, r *testRepo, branch, remote string) {
+	t.Helper()
+	cfg, err := r.repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Branches[branch] = &config.Branch{Name: branch, Remote: remote, Merge: plumbing.NewBranchReferenceName(branch)}
+	if err := r.repo.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpstreamRemoteLinks(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: first")
+	r.remote("origin", "https://github.com/acme/widget.git")
+	r.remote("upstream", "https://github.com/upstream/widget.git")
+	setUpstream(t, r, "master", "upstream")
+
+	gen, head := testGenerator(t, r.dir)
+	url := walkReleases(t, gen, head)[0].Changes[0].URL
+	if !strings.HasPrefix(url, "https://github.com/upstream/widget/commits/") {
+		t.Errorf("got %q, want a link to the upstream remote", url)
+	}
+}
+
+func TestPreferredRemote(t *testing.T) {
+	remotes := map[string]string{
+		"fork":     "https://github.com/me/widget.git",
+		"local":    "file:///srv/git/widget",
+		"origin":   "git@github.com:acme/widget.git",
+		"upstream": "https://github.com/upstream/widget.git",
+	}
+	tests := []struct {
+		name     string
+		priority []string
+		want     string
+	}{
+		{"first listed", []string{"upstream", "fork"}, "upstream"},
+		{"in the listed order", []string{"fork", "upstream"}, "fork"},
+		{"skipping missing remotes", []string{"gone", "fork"}, "fork"},
+		{"skipping unparseable remotes", []string{"local", "upstream"}, "upstream"},
+		{"falling back to origin", []string{"gone", "local"}, "origin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepo(t)
+			r.commit("feat: first")
+			for name, url := range remotes {
+				r.remote(name, url)
+			}
+			head, err := r.repo.Head()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := preferredRemote(r.repo, head, tt.priority); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreferredRemoteFallsBackByName(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: first")
+	// origin is what linkRemote picks, but can't be linked to
+	r.remote("origin", "file:///srv/git/widget")
+	r.remote("zeta", "https://github.com/zeta/widget.git")
+	r.remote("beta", "https://github.com/beta/widget.git")
+
+	gen, head := testGenerator(t, r.dir, "--remote-priority", "gone")
+	url := walkReleases(t, gen, head)[0].Changes[0].URL
+	if !strings.HasPrefix(url, "https://github.com/beta/widget/commits/") {
+		t.Errorf("got %q, want a link to the beta remote", url)
+	}
+}
@@ -0,0 +1,511 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const releaseTemplate = `{{ define "change" }}- {{ if .SHAFirst }}{{ if .URL }}[{{ .SHA }}]({{ .URL }}) {{ else if .SHA }}[{{ .SHA }}] {{ end }}{{ end }}{{ if .ScopeBadge }}{{ code .Scope }} {{ end }}{{ linkIssues .Title .Issues }}{{ if not .SHAFirst }}{{ if .URL }} [{{ .SHA }}]({{ .URL }}){{ else if .SHA }} [{{ .SHA }}]{{ end }}{{ end }}{{ with .Stats }} ({{ . }}){{ end }}{{ with .Branch }} (in {{ . }}){{ end }}{{ template "details" . }}{{ end -}}
+{{ define "details" }}{{ range .Commits }}
+  - {{ . }}{{ end }}{{ range .Submodules }}
+  - {{ .Path }}:{{ range .Changes }}
+    - {{ .Title }}{{ if .URL }} [{{ .SHA }}]({{ .URL }}){{ else }} [{{ .SHA }}]{{ end }}{{ end }}{{ end }}{{ if .Body }}
+
+{{ indent 2 .Body }}
+{{ end }}{{ end -}}
+## {{ if .NoVersionBrackets }}{{ .Version }}{{ else }}[{{ .Version }}]{{ end }} - {{ .Date }}
+{{ if .Groups }}{{ range .Groups }}
+### {{ .Title }}
+{{ if .Groups }}{{ range .Groups }}
+#### {{ .Title }}
+{{ range .Changes }}
+{{ template "change" . }}{{ end }}
+{{ end }}{{ else }}{{ range .Changes }}
+{{ template "change" . }}{{ else }}
+- No notable changes{{ end }}
+{{ end }}{{ end }}{{ else }}{{ range .Changes }}
+{{ template "change" . }}{{ else }}
+- No notable changes{{ end }}
+{{ end }}{{ if .Domains }}
+### Changes by Email Domain
+
+{{ range .Domains }}- {{ .Domain }}: {{ .Count }}
+{{ end }}{{ end }}`
+
+// referenceTemplate is the markdown format with Keep a Changelog style link
+// references: the version and commit hashes are bracketed in the text, and
+// their URLs are defined after the changes.
+const referenceTemplate = `{{ define "change" }}- {{ if and .SHAFirst .SHA }}[{{ .SHA }}] {{ end }}{{ if .ScopeBadge }}{{ code .Scope }} {{ end }}{{ linkIssues .Title .Issues }}{{ if and .SHA (not .SHAFirst) }} [{{ .SHA }}]{{ end }}{{ with .Stats }} ({{ . }}){{ end }}{{ with .Branch }} (in {{ . }}){{ end }}{{ template "details" . }}{{ end -}}
+{{ define "details" }}{{ range .Commits }}
+  - {{ . }}{{ end }}{{ if .Body }}
+
+{{ indent 2 .Body }}
+{{ end }}{{ end -}}
+## {{ if .NoVersionBrackets }}{{ .Version }}{{ else }}[{{ .Version }}]{{ end }} - {{ .Date }}
+{{ if .Groups }}{{ range .Groups }}
+### {{ .Title }}
+{{ if .Groups }}{{ range .Groups }}
+#### {{ .Title }}
+{{ range .Changes }}
+{{ template "change" . }}{{ end }}
+{{ end }}{{ else }}{{ range .Changes }}
+{{ template "change" . }}{{ else }}
+- No notable changes{{ end }}
+{{ end }}{{ end }}{{ else }}{{ range .Changes }}
+{{ template "change" . }}{{ else }}
+- No notable changes{{ end }}
+{{ end }}{{ if .Domains }}
+### Changes by Email Domain
+
+{{ range .Domains }}- {{ .Domain }}: {{ .Count }}
+{{ end }}{{ end }}
+{{ if and .CompareURL (not .NoVersionBrackets) }}[{{ .Version }}]: {{ .CompareURL }}
+{{ end }}{{ range .Changes }}{{ if .URL }}[{{ .SHA }}]: {{ .URL }}
+{{ end }}{{ end }}`
+
+// githubReleaseTemplate mirrors the notes GitHub generates for a release.
+// There's no version header since GitHub already shows the tag name.
+const githubReleaseTemplate = `{{ define "change" }}* {{ if .ScopeBadge }}{{ code .Scope }} {{ end }}{{ linkIssues .Title .Issues }}{{ if .SHA }} by {{ .Author }} in {{ if .URL }}[{{ .SHA }}]({{ .URL }}){{ else }}{{ .SHA }}{{ end }}{{ end }}{{ with .Stats }} ({{ . }}){{ end }}{{ with .Branch }} (in {{ . }}){{ end }}{{ template "details" . }}{{ end -}}
+{{ define "details" }}{{ range .Commits }}
+  * {{ . }}{{ end }}{{ range .Submodules }}
+  * {{ .Path }}:{{ range .Changes }}
+    * {{ .Title }} in {{ if .URL }}[{{ .SHA }}]({{ .URL }}){{ else }}{{ .SHA }}{{ end }}{{ end }}{{ end }}{{ if .Body }}
+
+{{ indent 2 .Body }}
+{{ end }}{{ end -}}
+## What's Changed
+{{ if .Groups }}{{ range .Groups }}
+### {{ .Title }}
+{{ if .Groups }}{{ range .Groups }}
+#### {{ .Title }}
+{{ range .Changes }}
+{{ template "change" . }}{{ end }}
+{{ end }}{{ else }}{{ range .Changes }}
+{{ template "change" . }}{{ else }}
+* No notable changes{{ end }}
+{{ end }}{{ end }}{{ else }}{{ range .Changes }}
+{{ template "change" . }}{{ else }}
+* No notable changes{{ end }}
+{{ end }}{{ if .Domains }}
+### Changes by Email Domain
+
+{{ range .Domains }}- {{ .Domain }}: {{ .Count }}
+{{ end }}{{ end }}{{ if .CompareURL }}
+**Full Changelog**: {{ .CompareURL }}
+{{ end }}`
+
+// slackTemplate renders Slack's mrkdwn, which has its own bold and link
+// syntax and no headings.
+const slackTemplate = `{{ define "change" }}• {{ if .ScopeBadge }}{{ code .Scope }} {{ end }}{{ linkIssues (escape .Title) .Issues }}{{ if .SHA }} ({{ link .SHA .URL }}){{ end }}{{ with .Stats }} ({{ . }}){{ end }}{{ with .Branch }} (in {{ . }}){{ end }}{{ template "details" . }}{{ end -}}
+{{ define "details" }}{{ range .Commits }}
+    ◦ {{ escape . }}{{ end }}{{ if .Body }}
+{{ indent 4 (escape .Body) }}{{ end }}{{ end -}}
+*{{ escape .Version }}* - {{ .Date }}
+{{ if .Groups }}{{ range .Groups }}
+*{{ escape .Title }}*{{ if .Groups }}{{ range .Groups }}
+_{{ escape .Title }}_{{ range .Changes }}
+{{ template "change" . }}{{ end }}{{ end }}{{ else }}{{ range .Changes }}
+{{ template "change" . }}{{ end }}{{ end }}
+{{ end }}{{ else }}{{ range .Changes }}
+{{ template "change" . }}{{ end }}
+{{ end }}{{ if .Domains }}
+*Changes by email domain*{{ range .Domains }}
+• {{ escape .Domain }}: {{ .Count }}{{ end }}
+{{ end }}{{ if .CompareURL }}
+{{ link "Full Changelog" .CompareURL }}
+{{ end }}`
+
+// discordTemplate renders the markdown Discord supports, which has no
+// tables and only three heading levels, keeping lines compact so releases
+// fit in as few messages as possible.
+const discordTemplate = `{{ define "change" }}- {{ if .ScopeBadge }}{{ code .Scope }} {{ end }}{{ linkIssues (escape .Title) .Issues }}{{ if .SHA }} ({{ link .SHA .URL }}){{ end }}{{ with .Stats }} ({{ . }}){{ end }}{{ with .Branch }} (in {{ . }}){{ end }}{{ template "details" . }}{{ end -}}
+{{ define "details" }}{{ range .Commits }}
+  - {{ escape . }}{{ end }}{{ if .Body }}
+{{ indent 2 (escape .Body) }}{{ end }}{{ end -}}
+## {{ escape .Version }} - {{ .Date }}
+{{ if .Groups }}{{ range .Groups }}
+### {{ escape .Title }}
+{{ if .Groups }}{{ range .Groups }}**{{ escape .Title }}**
+{{ range .Changes }}{{ template "change" . }}
+{{ end }}{{ end }}{{ else }}{{ range .Changes }}{{ template "change" . }}
+{{ end }}{{ end }}{{ end }}{{ else }}{{ range .Changes }}{{ template "change" . }}
+{{ else }}- No notable changes
+{{ end }}{{ end }}{{ if .Domains }}
+### Changes by Email Domain
+{{ range .Domains }}- {{ escape .Domain }}: {{ .Count }}
+{{ end }}{{ end }}{{ if .CompareURL }}
+{{ link "Full Changelog" .CompareURL }}
+{{ end }}`
+
+// markdownTableTemplate lists changes as table rows, one table per group.
+const markdownTableTemplate = `{{ define "change" }}| {{ link .SHA .URL }} | {{ .Type }} | {{ linkIssues (cell .Title) .Issues }} |{{ end -}}
+{{ define "table" }}
+| SHA | Type | Subject |
+| --- | ---- | ------- |
+{{ range . }}{{ template "change" . }}
+{{ end }}{{ end -}}
+## {{ if .NoVersionBrackets }}{{ .Version }}{{ else }}[{{ .Version }}]{{ end }} - {{ .Date }}
+{{ if .Groups }}{{ range .Groups }}
+### {{ .Title }}
+{{ if .Groups }}{{ range .Groups }}
+#### {{ .Title }}
+{{ template "table" .Changes }}{{ end }}{{ else }}{{ template "table" .Changes }}{{ end }}{{ end }}{{ else }}{{ template "table" .Changes }}{{ end }}{{ if .Domains }}
+### Changes by Email Domain
+
+{{ range .Domains }}- {{ .Domain }}: {{ .Count }}
+{{ end }}{{ end }}`
+
+// plainLinksTemplate is plain text with bare URLs, for wikis and other
+// places that make URLs clickable but don't render markdown.
+const plainLinksTemplate = `{{ define "change" }}- {{ linkIssues .Title .Issues }}{{ if .URL }} {{ .URL }}{{ else if .SHA }} ({{ .SHA }}){{ end }}{{ with .Stats }} ({{ . }}){{ end }}{{ with .Branch }} (in {{ . }}){{ end }}{{ template "details" . }}{{ end -}}
+{{ define "details" }}{{ range .Commits }}
+  - {{ . }}{{ end }}{{ range .Submodules }}
+  - {{ .Path }}:{{ range .Changes }}
+    - {{ .Title }}{{ if .URL }} {{ .URL }}{{ else }} ({{ .SHA }}){{ end }}{{ end }}{{ end }}{{ if .Body }}
+
+{{ indent 2 .Body }}
+{{ end }}{{ end -}}
+{{ .Version }} - {{ .Date }}
+{{ if .Groups }}{{ range .Groups }}
+{{ .Title }}
+{{ if .Groups }}{{ range .Groups }}
+{{ .Title }}:
+{{ range .Changes }}
+{{ template "change" . }}{{ end }}
+{{ end }}{{ else }}{{ range .Changes }}
+{{ template "change" . }}{{ else }}
+- No notable changes{{ end }}
+{{ end }}{{ end }}{{ else }}{{ range .Changes }}
+{{ template "change" . }}{{ else }}
+- No notable changes{{ end }}
+{{ end }}{{ if .CompareURL }}
+Full Changelog: {{ .CompareURL }}
+{{ end }}`
+
+// commitlintTemplate lists the commits that aren't conventional commits, one
+// per line, for the commitlint-check format.
+const commitlintTemplate = `{{ range nonConventional .Changes }}{{ .SHA }} {{ .Title }}
+{{ end }}`
+
+// htmlTemplate is parsed with html/template, so fields are escaped for the
+// context they appear in and untrusted commit subjects can't inject markup.
+const htmlTemplate = `{{ define "change" }}<li>{{ if .ScopeBadge }}{{ code .Scope }} {{ end }}{{ linkIssues .Title .Issues }}{{ if .SHA }} {{ link .SHA .URL }}{{ end }}{{ with .Stats }} ({{ . }}){{ end }}{{ with .Branch }} (in {{ . }}){{ end }}{{ template "details" . }}</li>{{ end -}}
+{{ define "details" }}{{ if .Commits }}<ul>{{ range .Commits }}<li>{{ . }}</li>{{ end }}</ul>{{ end }}{{ if .Body }}<p>{{ .Body }}</p>{{ end }}{{ end -}}
+<section>
+<h2>{{ .Version }} - {{ .Date }}</h2>
+{{ if .Groups }}{{ range .Groups }}<h3>{{ .Title }}</h3>
+{{ if .Groups }}{{ range .Groups }}<h4>{{ .Title }}</h4>
+<ul>
+{{ range .Changes }}{{ template "change" . }}
+{{ end }}</ul>
+{{ end }}{{ else }}<ul>
+{{ range .Changes }}{{ template "change" . }}
+{{ end }}</ul>
+{{ end }}{{ end }}{{ else }}<ul>
+{{ range .Changes }}{{ template "change" . }}
+{{ end }}</ul>
+{{ end }}{{ if .Domains }}<h3>Changes by Email Domain</h3>
+<ul>
+{{ range .Domains }}<li>{{ .Domain }}: {{ .Count }}</li>
+{{ end }}</ul>
+{{ end }}{{ if .CompareURL }}<p>{{ link "Full Changelog" .CompareURL }}</p>
+{{ end }}</section>
+`
+
+// bodyTemplate lists a release's changes through the "change" sub-template
+// of the format, for --body-only.
+const bodyTemplate = `{{ range .Changes }}{{ template "change" . }}
+{{ end }}`
+
+// renderer is the part of text/template and html/template used to render a
+// release.
+type renderer interface {
+	Execute(w io.Writer, data any) error
+}
+
+// parseTemplate parses a release template, replacing its "change"
+// sub-template with commitTmpl when one is given. Templates are parsed with
+// text/template unless engine is "html" or the format is an HTML one, which
+// use html/template for context-aware escaping. Data formats are encoded
+// without a template.
+func parseTemplate(format, engine, releaseTmpl, commitTmpl string) (renderer, error) {
+	f, err := lookupFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	if engine != "text" && engine != "html" {
+		return nil, errors.New(fmt.Sprintf("unsupported template-engine value: %s", engine))
+	}
+	switch {
+	case f.data != nil:
+		if releaseTmpl != "" || commitTmpl != "" {
+			return nil, errors.New(fmt.Sprintf("the %s format can't be combined with a template", f.name))
+		}
+		return f.data(), nil
+	case f.html || engine == "html":
+		tmpl, err := htmltemplate.New("release").Funcs(htmltemplate.FuncMap(templateFuncs(f))).Parse(releaseTmpl)
+		if err != nil {
+			return nil, err
+		}
+		if commitTmpl != "" {
+			if _, err := tmpl.New("change").Parse(commitTmpl); err != nil {
+				return nil, errors.Wrap(err, "failed to parse commit template")
+			}
+		}
+		return tmpl, nil
+	}
+
+	tmpl, err := template.New("release").Funcs(templateFuncs(f)).Parse(releaseTmpl)
+	if err != nil {
+		return nil, err
+	}
+	if commitTmpl != "" {
+		if _, err := tmpl.New("change").Parse(commitTmpl); err != nil {
+			return nil, errors.Wrap(err, "failed to parse commit template")
+		}
+	}
+	return tmpl, nil
+}
+
+// bodyOnly turns a parsed release template into one that renders just the
+// changes, without the heading before them and the links after them.
+func bodyOnly(tmpl renderer) (renderer, error) {
+	switch t := tmpl.(type) {
+	case *template.Template:
+		if t.Lookup("change") != nil {
+			return t.New("body").Parse(bodyTemplate)
+		}
+	case *htmltemplate.Template:
+		if t.Lookup("change") != nil {
+			return t.New("body").Parse(bodyTemplate)
+		}
+	}
+	return nil, errors.New(`--body-only needs a template that defines a "change" sub-template`)
+}
+
+// render executes tmpl for a single release.
+func render(tmpl renderer, release *Release) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, release); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readTemplate reads a custom template from a file or, when path is an
+// http(s) URL, fetches it.
+func readTemplate(cmd *cobra.Command, path string) (string, error) {
+	if strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://") {
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		retries, _ := cmd.Flags().GetInt("retries")
+		text, err := fetchURL(path, timeout, retries)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read template")
+		}
+		return string(text), nil
+	}
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read template")
+	}
+	return string(text), nil
+}
+
+// parseTemplateContext turns --template-context key=value pairs into the
+// map templates see as .Context. Values can contain further equals signs.
+func parseTemplateContext(pairs []string) (map[string]string, error) {
+	context := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, errors.New(fmt.Sprintf("invalid template-context value %q; expected key=value", pair))
+		}
+		context[strings.TrimSpace(key)] = value
+	}
+	return context, nil
+}
+
+// templateFuncs are the helpers available to both built-in and custom
+// templates. The link and escape helpers follow the syntax of the output
+// format.
+func templateFuncs(f *outputFormat) template.FuncMap {
+	link, escape, codeFunc := markdownLink, func(s string) string { return s }, code
+	if f.link != nil {
+		link = f.link
+	}
+	if f.escape != nil {
+		escape = f.escape
+	}
+	if f.code != nil {
+		codeFunc = f.code
+	}
+	funcs := template.FuncMap{
+		"link":       link,
+		"escape":     escape,
+		"linkIssues": linkIssues(link),
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"title":      titleCase,
+		"truncate":   truncate,
+		"date":       formatDate,
+		"indent":     indent,
+		"cell":       tableCell,
+		"code":       codeFunc,
+		// used by the commitlint-check format
+		"nonConventional": nonConventional,
+	}
+	if f.html {
+		// html/template escapes on its own and would escape the markup
+		// returned by the link helpers unless it's typed as HTML
+		funcs["link"] = htmlLink
+		funcs["linkIssues"] = htmlLinkIssues
+		funcs["code"] = htmlCode
+	}
+	return funcs
+}
+
+// code formats text as inline code, which markdown and Slack both write
+// between backticks.
+func code(text string) string {
+	return "`" + text + "`"
+}
+
+// sectionSeparator is written between the sections of several releases
+// rendered one after the other.
+func sectionSeparator(f *outputFormat) string {
+	switch {
+	case f.line:
+		return ""
+	case f.separator != "":
+		return f.separator
+	}
+	return "\n"
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// markdownLink renders a markdown link, or just the text when there's no
+// URL to link to.
+func markdownLink(text, url string) string {
+	if url == "" {
+		return text
+	}
+	return "[" + text + "](" + url + ")"
+}
+
+// plainLink follows text with its URL in parentheses, for formats where
+// bare URLs are made clickable.
+func plainLink(text, url string) string {
+	if url == "" {
+		return text
+	}
+	return text + " (" + url + ")"
+}
+
+// slackLink renders a link in Slack's <url|text> syntax.
+func slackLink(text, url string) string {
+	text = slackEscaper.Replace(text)
+	if url == "" {
+		return text
+	}
+	return "<" + url + "|" + text + ">"
+}
+
+// htmlLink renders an anchor, or just the escaped text when there's no
+// http(s) URL to link to.
+func htmlLink(text, rawURL string) htmltemplate.HTML {
+	u, err := url.Parse(rawURL)
+	if rawURL == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return htmltemplate.HTML(html.EscapeString(text))
+	}
+	return htmltemplate.HTML(`<a href="` + html.EscapeString(rawURL) + `">` + html.EscapeString(text) + `</a>`)
+}
+
+// htmlCode escapes text and marks it up as inline code.
+func htmlCode(text string) htmltemplate.HTML {
+	return htmltemplate.HTML("<code>" + html.EscapeString(text) + "</code>")
+}
+
+// htmlLinkIssues escapes title and links the issue keys in it.
+func htmlLinkIssues(title string, issues []Issue) htmltemplate.HTML {
+	link := func(text, url string) string { return string(htmlLink(text, url)) }
+	return htmltemplate.HTML(linkIssues(link)(html.EscapeString(title), issues))
+}
+
+var tableCellEscaper = strings.NewReplacer("|", "\\|", "\r\n", " ", "\n", " ")
+
+// tableCell escapes pipes and folds newlines so s fits in a markdown table
+// cell.
+func tableCell(s string) string {
+	return tableCellEscaper.Replace(s)
+}
+
+// indent prefixes every non-empty line of s with n spaces.
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = pad + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// titleCase upper-cases the first letter of every word.
+func titleCase(s string) string {
+	upper := true
+	return strings.Map(func(r rune) rune {
+		if upper {
+			r = unicode.ToUpper(r)
+		}
+		upper = unicode.IsSpace(r)
+		return r
+	}, s)
+}
+
+// truncate shortens s to at most n characters, marking the cut with an
+// ellipsis.
+func truncate(n int, s string) string {
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n-1]) + "…"
+}
+
+// formatDate formats a time.Time, or a date string in the YYYY-MM-DD form
+// used by Release.Date, with a Go time layout. Zero times and empty strings
+// format as an empty string rather than year one.
+func formatDate(layout string, value any) (string, error) {
+	switch v := value.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return "", nil
+		}
+		return v.Format(layout), nil
+	case string:
+		if v == "" {
+			return "", nil
+		}
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to parse date")
+		}
+		return t.Format(layout), nil
+	}
+	return "", errors.New(fmt.Sprintf("cannot format %T as a date", value))
+}
@@ -0,0 +1,530 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTitleCase(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"bug fixes", "Bug Fixes"},
+		{"already Title", "Already Title"},
+		{"  padded\twords ", "  Padded\tWords "},
+		{"émigré über", "Émigré Über"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := titleCase(tt.in); got != tt.want {
+			t.Errorf("titleCase(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		n        int
+		in, want string
+	}{
+		{10, "short", "short"},
+		{5, "exact", "exact"},
+		{5, "too long", "too …"},
+		// the cut falls on rune boundaries, not bytes
+		{4, "héllo wörld", "hél…"},
+		{3, "日本語テキスト", "日本…"},
+		// too short for anything but the ellipsis
+		{1, "abc", "…"},
+		// no limit
+		{0, "abc", "abc"},
+		{-1, "abc", "abc"},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.n, tt.in); got != tt.want {
+			t.Errorf("truncate(%d, %q) = %q, want %q", tt.n, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		layout  string
+		value   any
+		want    string
+		wantErr bool
+	}{
+		{"time", "Jan 2, 2006", time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC), "Mar 5, 2024", false},
+		{"release date", "02/01/2006", "2024-03-05", "05/03/2024", false},
+		{"zero time", "2006-01-02", time.Time{}, "", false},
+		{"empty date", "2006-01-02", "", "", false},
+		{"malformed date", "2006", "March 5th", "", true},
+		{"unsupported type", "2006", 2024, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatDate(tt.layout, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomTemplate(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: add pagination")
+	tmpl := filepath.Join(t.TempDir(), "release.tmpl")
+	err := os.WriteFile(tmpl, []byte(`{{ upper .Version }}{{ range .Changes }} {{ title (truncate 9 .Title) }}{{ end }}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := runSumit(t, r.dir, "--template", tmpl, "v1.0.0-rc"), "V1.0.0-RC Feat: Ad…"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestIndent(t *testing.T) {
+	tests := []struct {
+		n        int
+		in, want string
+	}{
+		{2, "one", "  one"},
+		{2, "one\ntwo", "  one\n  two"},
+		// blank lines stay blank instead of gaining trailing spaces
+		{4, "one\n\ntwo\n", "    one\n\n    two\n"},
+		{0, "one\ntwo", "one\ntwo"},
+		{2, "", ""},
+	}
+	for _, tt := range tests {
+		if got := indent(tt.n, tt.in); got != tt.want {
+			t.Errorf("indent(%d, %q) = %q, want %q", tt.n, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSlackFormat(t *testing.T) {
+	r := newTestRepo(t)
+	hash := r.commit("fix: escape <b> & friends").String()
+	r.remote("origin", "https://github.com/acme/widget.git")
+
+	got := lines(runSumit(t, r.dir, "--output-format", "slack", "1.0.0"))
+	want := "• fix: escape &lt;b&gt; &amp; friends (<https://github.com/acme/widget/commits/" + hash + "|" + hash[:7] + ">)"
+	if len(got) != 2 || !strings.HasPrefix(got[0], "*1.0.0* - ") || got[1] != want {
+		t.Errorf("got %q, want a bold version line and %q", got, want)
+	}
+}
+
+func TestCommitTemplate(t *testing.T) {
+	r := newTestRepo(t)
+	first := r.commitBy("Jane Doe", "jane@example.com", "feat(api): add pagination (#42)").String()
+	second := r.commitBy("John Roe", "john@example.com", "fix!: reject empty names").String()
+
+	tests := []struct {
+		name       string
+		commitTmpl string
+		want       []string
+	}{
+		{"title and sha", "- {{.Title}} ({{.SHA}})", []string{
+			"- fix!: reject empty names (" + second[:7] + ")",
+			"- feat(api): add pagination (#42) (" + first[:7] + ")",
+		}},
+		{"author", "* {{.Author}}: {{.Title}}", []string{
+			"* John Roe: fix!: reject empty names",
+			"* Jane Doe: feat(api): add pagination (#42)",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := runSumit(t, r.dir, "--commit-template", tt.commitTmpl, "1.1.0")
+			if got := lines(out)[1:]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitTemplateParseError(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: first")
+
+	out := runSumitError(t, r.dir, "--commit-template", "{{ .Title ", "1.0.0")
+	if !strings.Contains(out, "failed to parse commit template") {
+		t.Errorf("got %q, want a commit template parse error", out)
+	}
+}
+
+func TestHTMLFormatEscapesSubjects(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: render <script>alert(1)</script> safely")
+	r.remote("origin", "https://github.com/acme/widget.git")
+
+	out := runSumit(t, r.dir, "--output-format", "html", "1.0.0")
+	if strings.Contains(out, "<script>") {
+		t.Errorf("output has unescaped markup:\n%s", out)
+	}
+	for _, want := range []string{"<h2>1.0.0 - ", "&lt;script&gt;alert(1)&lt;/script&gt;", `<a href="https://github.com/acme/widget/commits/`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output has no %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestTableCell(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"a | b", "a \\| b"},
+		{"two\nlines", "two lines"},
+		{"windows\r\nlines", "windows lines"},
+	}
+	for _, tt := range tests {
+		if got := tableCell(tt.in); got != tt.want {
+			t.Errorf("tableCell(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdownTableFormat(t *testing.T) {
+	r := newTestRepo(t)
+	first := r.commit("feat: add a | b syntax").String()
+	second := r.commit("fix: handle empty input").String()
+
+	got := lines(runSumit(t, r.dir, "--output-format", "markdown-table", "--group-by", "type", "1.0.0"))
+	want := []string{
+		"### Features",
+		"| SHA | Type | Subject |",
+		"| --- | ---- | ------- |",
+		"| " + first[:7] + " | feat | feat: add a \\| b syntax |",
+		"### Bug Fixes",
+		"| SHA | Type | Subject |",
+		"| --- | ---- | ------- |",
+		"| " + second[:7] + " | fix | fix: handle empty input |",
+	}
+	if !reflect.DeepEqual(got[1:], want) {
+		t.Errorf("got %q, want %q", got[1:], want)
+	}
+}
+
+func TestNoVersionBrackets(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: first")
+	r.tag("v1.0.0")
+	r.commit("feat: second")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"brackets", []string{"--all-tags"}, []string{"## [Unreleased] - ", "## [1.0.0] - 2024-01-01"}},
+		{"no brackets", []string{"--all-tags", "--no-version-brackets"}, []string{"## Unreleased - ", "## 1.0.0 - 2024-01-01"}},
+		{"markdown-table", []string{"--no-version-brackets", "--output-format", "markdown-table", "2.0.0"}, []string{"## 2.0.0 - "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, line := range lines(runSumit(t, r.dir, tt.args...)) {
+				if strings.HasPrefix(line, "## ") {
+					got = append(got, line)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got headings %q, want %q", got, tt.want)
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(got[i], want) {
+					t.Errorf("got heading %q, want %q", got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestMarkdownRefsFormat(t *testing.T) {
+	r := newTestRepo(t)
+	r.remote("origin", "https://github.com/acme/widget.git")
+	r.commit("feat: first")
+	r.tag("v1.0.0")
+	hash := r.commit("feat: second").String()
+
+	got := lines(runSumit(t, r.dir, "--output-format", "markdown-refs", "1.1.0"))
+	want := []string{
+		"- feat: second [" + hash[:7] + "]",
+		"[1.1.0]: https://github.com/acme/widget/compare/v1.0.0...v1.1.0",
+		"[" + hash[:7] + "]: https://github.com/acme/widget/commits/" + hash,
+	}
+	if !reflect.DeepEqual(got[1:], want) {
+		t.Errorf("got %q, want %q", got[1:], want)
+	}
+}
+
+func TestEmptyReleasePlaceholder(t *testing.T) {
+	tests := []struct {
+		format, want string
+	}{
+		{"markdown", "\n- No notable changes"},
+		{"markdown-refs", "\n- No notable changes"},
+		{"github-release", "\n* No notable changes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			release := &Release{Version: "1.0.0", Date: "2024-01-01"}
+			if out := renderFormat(t, tt.format, release); !strings.Contains(out, tt.want) {
+				t.Errorf("output has no %q:\n%s", tt.want, out)
+			}
+			release.Changes = sampleRelease().Changes
+			if out := renderFormat(t, tt.format, release); strings.Contains(out, "No notable changes") {
+				t.Errorf("output of a release with changes has the placeholder:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestGroupedReleaseWithoutChanges(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("chore: tidy")
+
+	for _, format := range []string{"markdown", "github-release"} {
+		t.Run(format, func(t *testing.T) {
+			out := runSumit(t, r.dir, "--output-format", format, "--group-by", "type", "--include-types", "feat", "1.0.0")
+			if !strings.Contains(out, "No notable changes") {
+				t.Errorf("output has no placeholder:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestScopeBadges(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat(api): paginate")
+	r.commit("fix: <no scope>")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"markdown", nil, []string{"- `api` feat(api): paginate [", "- fix: <no scope> ["}},
+		{"html", []string{"--output-format", "html"}, []string{"<li><code>api</code> feat(api): paginate ", "<li>fix: &lt;no scope&gt; "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := runSumit(t, r.dir, append(tt.args, "--scope-badges", "1.0.0")...)
+			for _, want := range tt.want {
+				if !strings.Contains(out, "\n"+want) {
+					t.Errorf("output has no line starting with %q:\n%s", want, out)
+				}
+			}
+		})
+	}
+
+	if out := runSumit(t, r.dir, "1.0.0"); strings.Contains(out, "`api`") {
+		t.Errorf("output has a badge without --scope-badges:\n%s", out)
+	}
+}
+
+func TestCode(t *testing.T) {
+	if got, want := code("api"), "`api`"; got != want {
+		t.Errorf("code = %q, want %q", got, want)
+	}
+	if got, want := htmlCode("<ui>"), "<code>&lt;ui&gt;</code>"; string(got) != want {
+		t.Errorf("htmlCode = %q, want %q", got, want)
+	}
+}
+
+func TestParseTemplateContext(t *testing.T) {
+	tests := []struct {
+		pairs   []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{nil, map[string]string{}, false},
+		{[]string{"projectName=widget", " env =prod"}, map[string]string{"projectName": "widget", "env": "prod"}, false},
+		{[]string{"query=a=b"}, map[string]string{"query": "a=b"}, false},
+		{[]string{"empty="}, map[string]string{"empty": ""}, false},
+		{[]string{"a=1", "a=2"}, map[string]string{"a": "2"}, false},
+		{[]string{"novalue"}, nil, true},
+		{[]string{"=value"}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseTemplateContext(tt.pairs)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseTemplateContext(%q) = %v, %v, want %v", tt.pairs, got, err, tt.want)
+		}
+	}
+}
+
+func TestTemplateContext(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: first")
+	tmplPath := filepath.Join(t.TempDir(), "release.tmpl")
+	if err := os.WriteFile(tmplPath, []byte("{{ .Context.projectName }} {{ .Version }} ({{ .Context.env }})\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := runSumit(t, r.dir, "--template", tmplPath, "--template-context", "projectName=widget", "--template-context", "env=prod", "1.0.0")
+	if want := "widget 1.0.0 (prod)\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestPlainLinksFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		remote string
+		want   string
+	}{
+		{"with remote", "https://github.com/acme/widget.git", "- feat: a https://github.com/acme/widget/commits/"},
+		{"without remote", "", "- feat: a ("},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepo(t)
+			if tt.remote != "" {
+				r.remote("origin", tt.remote)
+			}
+			hash := r.commit("feat: a")
+
+			out := runSumit(t, r.dir, "--output-format", "plain-links", "1.0.0")
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output has no %q:\n%s", tt.want, out)
+			}
+			if strings.Contains(out, "](") {
+				t.Errorf("output has markdown links:\n%s", out)
+			}
+			if tt.remote == "" && !strings.Contains(out, "("+hash.String()[:7]+")") {
+				t.Errorf("output has no short hash:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestBodyOnly(t *testing.T) {
+	r := newTestRepo(t)
+	r.remote("origin", "https://github.com/acme/widget.git")
+	r.commit("feat: a")
+	r.tag("v1.0.0")
+	r.commit("fix: b")
+	r.commit("feat: c")
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"markdown", []string{"- feat: c [", "- fix: b ["}},
+		{"github-release", []string{"* feat: c by Jane Doe in [", "* fix: b by Jane Doe in ["}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			out := runSumit(t, r.dir, "--body-only", "--output-format", tt.format, "1.1.0")
+			got := lines(out)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %q, want only the changes", got)
+			}
+			for i, line := range got {
+				if !strings.HasPrefix(line, tt.want[i]) {
+					t.Errorf("line %d is %q, want it to start with %q", i+1, line, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBodyOnlyNeedsChangeTemplate(t *testing.T) {
+	tmpl, err := parseTemplate("markdown", "text", "{{ .Version }}", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bodyOnly(tmpl); err == nil {
+		t.Error("got no error for a template without a change sub-template")
+	}
+}
+
+func TestSHAPosition(t *testing.T) {
+	r := newTestRepo(t)
+	r.remote("origin", "https://github.com/acme/widget.git")
+	hash := r.commit("feat: a")
+	sha := hash.String()[:7]
+	link := "[" + sha + "](https://github.com/acme/widget/commits/" + hash.String() + ")"
+
+	tests := []struct {
+		format, position string
+		want             string
+	}{
+		{"markdown", "", "- feat: a " + link},
+		{"markdown", "after", "- feat: a " + link},
+		{"markdown", "before", "- " + link + " feat: a"},
+		{"markdown-refs", "after", "- feat: a [" + sha + "]"},
+		{"markdown-refs", "before", "- [" + sha + "] feat: a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.position, func(t *testing.T) {
+			args := []string{"--output-format", tt.format}
+			if tt.position != "" {
+				args = append(args, "--sha-position", tt.position)
+			}
+			out := runSumit(t, r.dir, append(args, "1.0.0")...)
+			if !strings.Contains(out, "\n"+tt.want+"\n") {
+				t.Errorf("got\n%s\nwant a line %q", out, tt.want)
+			}
+		})
+	}
+
+	_, _, err := newTestGenerator(t, r.dir, "--sha-position", "middle")
+	if err == nil || !strings.Contains(err.Error(), "unsupported sha-position value: middle") {
+		t.Errorf("got %v, want an unsupported sha-position error", err)
+	}
+}
+
+func TestTemplateEngine(t *testing.T) {
+	const script = "<script>alert(1)</script>"
+	release := &Release{Version: "1.0.0", Changes: []Change{{Title: "feat: " + script}}}
+	const tmpl = `{{ range .Changes }}<li>{{ .Title }}</li>{{ end }}`
+	tests := []struct {
+		name, format, engine string
+		want                 string
+	}{
+		{"text", "markdown", "text", "<li>feat: " + script + "</li>"},
+		{"html", "markdown", "html", "<li>feat: &lt;script&gt;alert(1)&lt;/script&gt;</li>"},
+		{"html format", "html", "text", "<li>feat: &lt;script&gt;alert(1)&lt;/script&gt;</li>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := parseTemplate(tt.format, tt.engine, tmpl, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := render(parsed, release)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("got %q, want %q", out, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseTemplate("markdown", "jinja", tmpl, ""); err == nil || !strings.Contains(err.Error(), "unsupported template-engine value: jinja") {
+		t.Errorf("got %v, want an unsupported template-engine error", err)
+	}
+}
+
+func TestTemplateEngineFlag(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: <script>alert(1)</script>")
+
+	out := runSumit(t, r.dir, "--template-engine", "html", "--commit-template", "- {{ .Title }}", "1.0.0")
+	if !strings.Contains(out, "- feat: &lt;script&gt;alert(1)&lt;/script&gt;") || strings.Contains(out, "<script>") {
+		t.Errorf("subject isn't escaped:\n%s", out)
+	}
+}
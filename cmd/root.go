@@ -1,122 +1,525 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
-	"text/template"
 	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
 var (
 	ErrStopIteration = errors.New("stop iteration")
+
+	// ErrNotARepo is returned when --dir isn't inside a git repository.
+	ErrNotARepo = errors.New("not a git repository")
+	// ErrNoCommits is returned for a repository without any commits yet.
+	ErrNoCommits = errors.New("no commits")
+	// ErrInvalidRange is matched by a RefError, for a ref given to pick the
+	// commits, such as --from, --to, --ref or --base, that can't be resolved.
+	ErrInvalidRange = errors.New("invalid range")
+	// ErrUnsupportedFormat is returned for an unknown --output-format.
+	ErrUnsupportedFormat = errors.New("unsupported output format")
 )
 
 func init() {
 	rootCmd.PersistentFlags().StringP("dir", "d", ".", "Set the working directory")
+	rootCmd.PersistentFlags().String("group-by", "", "Group changes under headings (author, type, scope), or nested headings such as type,scope")
+	rootCmd.PersistentFlags().Bool("keep-empty-sections", false, "Give every conventional type a section when grouping by type, even without changes")
+	rootCmd.PersistentFlags().StringArray("type-title", nil, "Title the section of a conventional type, e.g. feat='New Stuff', or of breaking changes (repeatable)")
+	rootCmd.PersistentFlags().StringSlice("include-types", nil, "Only include commits of these conventional types")
+	rootCmd.PersistentFlags().Bool("keep-unmatched", false, "Keep non-conventional commits when --include-types is set")
+	rootCmd.PersistentFlags().StringP("output", "o", "", "Write the changelog to a file instead of stdout")
+	rootCmd.PersistentFlags().String("prepend", "", "Insert the release at the top of an existing changelog file")
+	rootCmd.PersistentFlags().String("append", "", "Insert the release at the bottom of an existing changelog file")
+	rootCmd.PersistentFlags().Bool("tee", false, "Also print the changelog to stdout when writing it to a file")
+	rootCmd.PersistentFlags().String("split-output", "", "Write one file per group into a directory")
+	rootCmd.PersistentFlags().Bool("with-unreleased", false, "Show the latest tagged release, or the releases since the given one, after an Unreleased section with the commits made since")
+	rootCmd.PersistentFlags().String("unreleased-output", "", "Write unreleased changes to this file and the latest release to --output")
+	rootCmd.PersistentFlags().Int("abbrev", 7, "Number of characters used for abbreviated commit hashes")
+	rootCmd.PersistentFlags().Bool("abbrev-minimal", false, "Lengthen abbreviated hashes until they're unambiguous (scans all objects)")
+	rootCmd.PersistentFlags().Bool("skip-release-commits", false, "Leave out version bump commits matching --release-commit-pattern")
+	rootCmd.PersistentFlags().String("release-commit-pattern", `(?i)^chore\(release\):|bump version`, "Regular expression matched against subjects by --skip-release-commits")
+	rootCmd.PersistentFlags().Bool("drop-merge-subjects", false, "Leave out merge commits while keeping the commits they merged")
+	rootCmd.PersistentFlags().Bool("exclude-bots", false, "Leave out commits by CI and dependency update bots such as dependabot and renovate")
+	rootCmd.PersistentFlags().StringArray("bot-pattern", nil, "Extra regular expression matched against author names and emails by --exclude-bots (repeatable)")
+	rootCmd.PersistentFlags().Duration("max-age", 0, "Leave out commits older than this, e.g. 720h for 30 days (0 keeps every commit)")
+	rootCmd.PersistentFlags().Bool("prs-only", false, "Only include commits that refer to a pull request, such as squash merges ending in (#42)")
+	rootCmd.PersistentFlags().Bool("dedupe", false, "Drop changes that duplicate a newer one")
+	rootCmd.PersistentFlags().String("dedupe-by", "subject", "Key used by --dedupe (subject, normalized, pr)")
+	rootCmd.PersistentFlags().Bool("with-body", false, "Include commit bodies, or the squashed commits of a squash merge")
+	rootCmd.PersistentFlags().Int("max-body-bytes", 4096, "Truncate commit bodies longer than this many bytes (0 for no limit)")
+	rootCmd.PersistentFlags().StringSlice("include-body-for-types", nil, "Only include bodies for these conventional types (\"breaking\" for breaking changes)")
+	rootCmd.PersistentFlags().String("jira-base", "", "Link Jira issue keys such as PROJ-123 to this Jira instance")
+	rootCmd.PersistentFlags().Bool("untyped-scopes", false, "Read the scope of subjects such as '(api): fix thing' that have no conventional type")
+	rootCmd.PersistentFlags().Bool("gitmoji", false, "Categorize commits by their leading gitmoji")
+	rootCmd.PersistentFlags().Bool("smart-subject", false, "Take the whole first paragraph of a commit message as its subject, for subjects wrapped onto several lines")
+	rootCmd.PersistentFlags().StringArray("strip-prefix", nil, "Remove this prefix from the start of subjects, or one matching a /regular expression/ (repeatable)")
+	rootCmd.PersistentFlags().Bool("strip-emoji", false, "Remove the emoji and :shortcodes: a subject starts with")
+	rootCmd.PersistentFlags().String("require-signoff", "", "Warn about commits without a Signed-off-by trailer (warn), or drop them (exclude)")
+	rootCmd.PersistentFlags().Lookup("require-signoff").NoOptDefVal = "warn"
+	rootCmd.PersistentFlags().String("fragments-dir", ".changelog.d", "Directory of hand-written entries to add to the release, relative to --dir")
+	rootCmd.PersistentFlags().String("merge-notes", "", "Add the bullets of a markdown release notes file to the release, replacing matching commits")
+	rootCmd.PersistentFlags().String("category-trailer", "Changelog", "Trailer whose value overrides a commit's type")
+	rootCmd.PersistentFlags().Bool("all-tags", false, "Generate a section for every tag in the history")
+	rootCmd.PersistentFlags().String("compare-from-initial", "", "Link the first release's changes from the first commit, or from the given ref, since it has no previous tag to compare with")
+	rootCmd.PersistentFlags().Lookup("compare-from-initial").NoOptDefVal = initialCommitRef
+	rootCmd.PersistentFlags().Bool("dedupe-across-releases", false, "With --all-tags, only list each commit in the oldest release that has it")
+	rootCmd.PersistentFlags().Bool("toc", false, "Start --all-tags output with a list of links to every release")
+	rootCmd.PersistentFlags().Bool("branch-only", false, "Only include commits made on the current branch since it diverged from --base")
+	rootCmd.PersistentFlags().String("base", "", "Branch the current branch is compared against for --branch-only (default: origin's default branch, main or master)")
+	rootCmd.PersistentFlags().String("from", "", "Start the changelog after this ref instead of the previous tag")
+	rootCmd.PersistentFlags().String("to", "", "End the changelog at this ref instead of HEAD")
+	rootCmd.PersistentFlags().String("ref", "", "Generate the changelog from this branch, tag or commit instead of HEAD, without checking it out")
+	rootCmd.PersistentFlags().String("tag-prefix", "v", "Prefix that turns a version into its git tag name")
+	rootCmd.PersistentFlags().Bool("relative-dates", false, "Show dates relative to today, such as 3 days ago, instead of YYYY-MM-DD")
+	rootCmd.PersistentFlags().String("date-source", "author", "Date used for commits and tagged releases (author, committer)")
+	rootCmd.PersistentFlags().Bool("include-nonsemver-tags", false, "Treat every tag as a release, not just --tag-prefix followed by a semantic version")
+	rootCmd.PersistentFlags().String("tag-name", "", "Git tag of the release, when it isn't the version with --tag-prefix")
+	rootCmd.PersistentFlags().StringSlice("remote-priority", nil, "Remotes to link to, in order of preference, e.g. upstream,origin")
+	rootCmd.PersistentFlags().String("base-url", "", "Build links on this URL, such as https://github.com/acme/widget, instead of the remote's")
+	rootCmd.PersistentFlags().Bool("fetch-tags", false, "Fetch tags from origin before looking for the previous release")
+	rootCmd.PersistentFlags().Duration("timeout", 30*time.Second, "Time limit for each attempt at a network operation")
+	rootCmd.PersistentFlags().Int("retries", 2, "Number of times a failed network operation is retried")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Suppress progress output")
+	rootCmd.PersistentFlags().Bool("strict", false, "Fail instead of warning about problems such as an unparseable remote or empty subjects")
+	rootCmd.PersistentFlags().String("template", "", "Render with a custom template file, or one fetched from an http(s) URL, instead of the built-in one")
+	rootCmd.PersistentFlags().StringArray("template-context", nil, "Make key=value available to templates as .Context.key (repeatable)")
+	rootCmd.PersistentFlags().String("email-subject", "", "Template for the subject of the email format, e.g. 'widget {{ .Version }} is out'")
+	rootCmd.PersistentFlags().String("template-engine", "text", "Render templates with text/template or, to escape commit subjects for web pages, html/template (text, html)")
+	rootCmd.PersistentFlags().String("commit-template", "", "Template for each change's line, e.g. '- {{ .Title }} ({{ .SHA }})'")
+	rootCmd.PersistentFlags().Bool("body-only", false, "Only render the changes, without the heading and links around them, e.g. for gh release create --notes-file -")
+	rootCmd.PersistentFlags().Bool("normalize-whitespace", false, "Collapse repeated blank lines and trim blank lines around the output")
+	rootCmd.PersistentFlags().Int("wrap", 0, "Wrap bullet lines at this many columns (0 disables wrapping)")
+	rootCmd.PersistentFlags().Bool("priority-sort", false, "List breaking changes first, then features, fixes and the other types, without grouping them")
+	rootCmd.PersistentFlags().Bool("collapse-prs", false, "Merge the commits that refer to the same pull request into one entry")
+	rootCmd.PersistentFlags().Bool("include-hash-in-title", false, "Add the short hash to each change's title, so it survives when links are stripped")
+	rootCmd.PersistentFlags().Bool("go-api-only", false, "Experimental: only include commits that change exported declarations in Go files")
+	rootCmd.PersistentFlags().Bool("include-submodules", false, "List the commits of each submodule a commit moved the pointer of, under the commit (submodules must be checked out)")
+	rootCmd.PersistentFlags().Bool("show-branch", false, "Show the tag or branch each commit first appeared on (walks the history of every tag and branch, which is slow on large repositories)")
+	rootCmd.PersistentFlags().Bool("show-stats", false, "Show how many files and lines each commit changed (diffs every commit, which is slow on long histories)")
+	rootCmd.PersistentFlags().String("sha-position", "after", "Where the commit hash goes in markdown bullets (before, after)")
+	rootCmd.PersistentFlags().Bool("scope-badges", false, "Show each change's conventional scope as an inline code badge")
+	rootCmd.PersistentFlags().Bool("domain-summary", false, "Summarize how many changes came from each author email domain")
+	rootCmd.PersistentFlags().Bool("no-version-brackets", false, "Leave the square brackets out of version headings")
+	rootCmd.PersistentFlags().String("output-format", "markdown", "Set the output format ("+strings.Join(formatNames(), ", ")+")")
+	rootCmd.PersistentFlags().Bool("no-header", false, "Leave the header row out of csv output")
+
+	rootCmd.MarkFlagsMutuallyExclusive("output", "prepend", "append", "split-output")
+	rootCmd.MarkFlagsMutuallyExclusive("unreleased-output", "all-tags", "with-unreleased")
+	rootCmd.MarkFlagsMutuallyExclusive("body-only", "all-tags", "with-unreleased")
+	rootCmd.MarkFlagsMutuallyExclusive("toc", "prepend", "append")
+	rootCmd.MarkFlagsMutuallyExclusive("ref", "to")
 }
 
 func bail(err error) {
 	if err == nil { return }
-	fmt.Printf("\n\x1b[31;1m%+v\x1b[0m\n", fmt.Sprintf("error: %s", err))
+	fmt.Fprintf(os.Stderr, "\n%s\n", colorize(os.Stderr, colorError, fmt.Sprintf("error: %s", err)))
 	os.Exit(1)
 }
 
 type Change struct {
-	SHA      string
-	Title    string
-	URL      string
+	SHA       string              `json:"sha,omitempty" yaml:"sha,omitempty"`
+	Title     string              `json:"title" yaml:"title"`
+	URL       string              `json:"url,omitempty" yaml:"url,omitempty"`
+	Author    string              `json:"author,omitempty" yaml:"author,omitempty"`
+	Email     string              `json:"email,omitempty" yaml:"email,omitempty"`
+	Date      string              `json:"date,omitempty" yaml:"date,omitempty"`
+	Type      string              `json:"type,omitempty" yaml:"type,omitempty"`
+	Scope     string              `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Breaking  bool                `json:"breaking" yaml:"breaking"`
+	Merge     bool                `json:"merge" yaml:"merge"`
+	PR        int                 `json:"pr,omitempty" yaml:"pr,omitempty"`
+	Body      string              `json:"body,omitempty" yaml:"body,omitempty"`
+	Commits   []string            `json:"commits,omitempty" yaml:"commits,omitempty"`
+	Issues    []Issue             `json:"issues,omitempty" yaml:"issues,omitempty"`
+	Trailers  map[string][]string `json:"trailers,omitempty" yaml:"trailers,omitempty"`
+	SignedOff bool                `json:"signed_off" yaml:"signed_off"`
+	// Stats is the size of the commit's diff, with --show-stats.
+	Stats *DiffStats `json:"stats,omitempty" yaml:"stats,omitempty"`
+	// Branch is the tag or branch the commit first appeared on, with
+	// --show-branch.
+	Branch string `json:"branch,omitempty" yaml:"branch,omitempty"`
+	// Submodules are the commits brought in by moving submodule pointers,
+	// with --include-submodules.
+	Submodules []SubmoduleChanges `json:"submodules,omitempty" yaml:"submodules,omitempty"`
+	// ScopeBadge is set when the scope should be shown ahead of the title.
+	ScopeBadge bool `json:"-" yaml:"-"`
+	// SHAFirst is set when the hash should be shown ahead of the title
+	// rather than after it.
+	SHAFirst bool `json:"-" yaml:"-"`
+	// Conventional is set when the subject follows the conventional commit
+	// format.
+	Conventional bool `json:"conventional" yaml:"conventional"`
+	// Fragment is set on hand-written entries and merged release notes,
+	// which have no commit.
+	Fragment bool `json:"fragment" yaml:"fragment"`
+}
+
+type Group struct {
+	Title   string   `json:"title" yaml:"title"`
+	Changes []Change `json:"changes" yaml:"changes"`
+	// Groups splits Changes further when --group-by has several levels.
+	Groups []Group `json:"groups,omitempty" yaml:"groups,omitempty"`
 }
 
 type Release struct {
-	Version string
-	Date    string
-	Changes []Change
+	Version     string   `json:"version" yaml:"version"`
+	Tag         string   `json:"tag,omitempty" yaml:"tag,omitempty"`
+	Date        string   `json:"date" yaml:"date"`
+	PreviousTag string   `json:"previous_tag,omitempty" yaml:"previous_tag,omitempty"`
+	CompareURL  string   `json:"compare_url,omitempty" yaml:"compare_url,omitempty"`
+	Changes     []Change `json:"changes,omitempty" yaml:"changes,omitempty"`
+	Groups      []Group  `json:"groups,omitempty" yaml:"groups,omitempty"`
+	// NoVersionBrackets drops the Keep a Changelog style brackets around
+	// the version in the heading.
+	NoVersionBrackets bool `json:"-" yaml:"-"`
+	// Domains counts the changes per author email domain, when
+	// --domain-summary is set.
+	Domains []DomainCount `json:"domains,omitempty" yaml:"domains,omitempty"`
+	// Context holds the values passed with --template-context.
+	Context map[string]string `json:"context,omitempty" yaml:"context,omitempty"`
+	// Tagger and TaggerDate identify who made the release's tag and when,
+	// for annotated tags only.
+	Tagger     string `json:"tagger,omitempty" yaml:"tagger,omitempty"`
+	TaggerDate string `json:"tagger_date,omitempty" yaml:"tagger_date,omitempty"`
+}
+
+// DiffStats counts the files a commit changed and the lines it added and
+// removed, compared with its first parent.
+type DiffStats struct {
+	Files      int `json:"files" yaml:"files"`
+	Insertions int `json:"insertions" yaml:"insertions"`
+	Deletions  int `json:"deletions" yaml:"deletions"`
+}
+
+func (s DiffStats) String() string {
+	files := "files"
+	if s.Files == 1 {
+		files = "file"
+	}
+	return fmt.Sprintf("%d %s, +%d -%d", s.Files, files, s.Insertions, s.Deletions)
+}
+
+// SubmoduleChanges are the commits of a submodule that a change moved its
+// pointer past.
+type SubmoduleChanges struct {
+	Path    string   `json:"path" yaml:"path"`
+	Changes []Change `json:"changes" yaml:"changes"`
 }
 
-const releaseTemplate = `## [{{ .Version }}] - {{ .Date }}
-{{ range .Changes }}
-- {{ .Title }} {{ if .URL }}[{{ .SHA }}]({{ .URL }}){{ else }}[{{ .SHA }}]{{ end }}{{ end }}
-`
+// DomainCount is the number of changes authored from an email domain.
+type DomainCount struct {
+	Domain string `json:"domain" yaml:"domain"`
+	Count  int    `json:"count" yaml:"count"`
+}
 
 var rootCmd = &cobra.Command{
-	Use: "sumit",
+	Use: "sumit [version]",
 	Short: "Generate a changelog from the git history",
-	Args: cobra.MinimumNArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		allTags, _ := cmd.Flags().GetBool("all-tags")
+		withUnreleased, _ := cmd.Flags().GetBool("with-unreleased")
+		if allTags || withUnreleased || flagChanged(cmd, "tag-name") || flagChanged(cmd, "unreleased-output") {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		version := args[0]
-		dir, _ := cmd.Flags().GetString("dir")
-		if dir == "" {
-			dir = "."
+		var version string
+		if len(args) > 0 {
+			version = args[0]
 		}
-
-		repo, err := git.PlainOpen(dir)
-		if err != nil {
-			bail(errors.Wrap(err, "failed to open git repository"))
+		// the version is what's displayed and the tag name is what's used to
+		// find the release in git; either can be derived from the other
+		tagPrefix, _ := cmd.Flags().GetString("tag-prefix")
+		tagName, _ := cmd.Flags().GetString("tag-name")
+		switch {
+		case tagName == "" && version != "":
+			tagName = tagPrefix + strings.TrimPrefix(version, tagPrefix)
+		case version == "" && tagName != "":
+			version = strings.TrimPrefix(tagName, tagPrefix)
+		}
+		groupBy, _ := cmd.Flags().GetString("group-by")
+		groupLevels, err := parseGroupBy(groupBy)
+		bail(err)
+		format, _ := cmd.Flags().GetString("output-format")
+		outFormat, err := lookupFormat(format)
+		bail(err)
+		releaseTmpl := outFormat.template
+		if outFormat.groupByType && groupBy == "" {
+			groupLevels = []string{"type"}
 		}
 
-		rem, err := repo.Remote("origin")
-		var useURL bool
-		var remoteURL string
-		if err == nil {
-			url := rem.Config().URLs[0]
-			remoteURL, err = parseRemoteURL(url)
+		templatePath, _ := cmd.Flags().GetString("template")
+		if templatePath != "" {
+			releaseTmpl, err = readTemplate(cmd, templatePath)
 			bail(err)
-			useURL = true
 		}
+		commitTmpl, _ := cmd.Flags().GetString("commit-template")
+		engine, _ := cmd.Flags().GetString("template-engine")
+		tmpl, err := parseTemplate(format, engine, releaseTmpl, commitTmpl)
+		bail(err)
+		if bodyOnlyFlag, _ := cmd.Flags().GetBool("body-only"); bodyOnlyFlag {
+			tmpl, err = bodyOnly(tmpl)
+			bail(err)
+		}
+		if outFormat.envelope != nil {
+			tmpl, err = outFormat.envelope(tmpl, cmd.Flags())
+			bail(err)
+		}
+		// next renders the releases that follow the first one in the same
+		// output
+		next := tmpl
+		if outFormat.continued != nil {
+			next = outFormat.continued()
+			if noHeader, _ := cmd.Flags().GetBool("no-header"); noHeader {
+				tmpl = next
+			}
+		}
+		wrap, _ := cmd.Flags().GetInt("wrap")
+		normalize, _ := cmd.Flags().GetBool("normalize-whitespace")
+		decorate := func(r renderer) renderer {
+			if wrap > 0 {
+				r = wrapRenderer{r, wrap}
+			}
+			if normalize {
+				r = normalizeRenderer{r}
+			}
+			if outFormat.wrap != nil {
+				r = outFormat.wrap(r)
+			}
+			return r
+		}
+		tmpl, next = decorate(tmpl), decorate(next)
 
-		ref, err := repo.Head()
-		if err != nil {
-			bail(errors.Wrap(err, "failed to get head ref"))
+		gen, head, err := newGenerator(cmd)
+		bail(err)
+		gen.groupBy = groupLevels
+		if version != "" && !isSemverTag(version, "") {
+			bail(gen.warn("version %s isn't a semantic version", version))
+		}
+		withUnreleased, _ := cmd.Flags().GetBool("with-unreleased")
+		if tagName != "" && gen.to == "" && gen.ref == "" && !withUnreleased {
+			// generate an existing release from its tag rather than HEAD
+			if hash, err := gen.repo.ResolveRevision(plumbing.Revision("refs/tags/" + tagName)); err == nil {
+				head = *hash
+			}
 		}
 
-		iter, err := repo.Log(&git.LogOptions{From: ref.Hash()})
-		if err != nil {
-			bail(errors.Wrap(err, "failed to get commit log"))
+		output, _ := cmd.Flags().GetString("output")
+		prependPath, _ := cmd.Flags().GetString("prepend")
+		appendPath, _ := cmd.Flags().GetString("append")
+		splitDir, _ := cmd.Flags().GetString("split-output")
+		tee, _ := cmd.Flags().GetBool("tee")
+
+		unreleasedPath, _ := cmd.Flags().GetString("unreleased-output")
+		if unreleasedPath != "" {
+			// the walk yields the untagged commits first, if there are any,
+			// followed by the latest tagged release
+			var unreleased, latest *Release
+			err = gen.walk(head, "", "", true, func(release *Release) error {
+				if release.Tag == "" {
+					unreleased = release
+					return nil
+				}
+				latest = release
+				return ErrStopIteration
+			})
+			bail(err)
+			if unreleased == nil {
+				unreleased = gen.finishHead(&Release{Version: "Unreleased", Date: now().Format("2006-01-02")})
+			}
+
+			section, err := render(tmpl, unreleased)
+			bail(err)
+			bail(writeOutput(unreleasedPath, section))
+			if output != "" && latest != nil {
+				section, err := render(tmpl, latest)
+				bail(err)
+				bail(writeOutput(output, section))
+			}
+			return
 		}
 
-		date := time.Now().Format("2006-01-02")
+		allTags, _ := cmd.Flags().GetBool("all-tags")
+		toc, _ := cmd.Flags().GetBool("toc")
+		if toc && (!allTags || !outFormat.toc) {
+			bail(errors.New("--toc requires --all-tags and a markdown output format"))
+		}
+		dedupeAcross, _ := cmd.Flags().GetBool("dedupe-across-releases")
+		if dedupeAcross && !allTags {
+			bail(errors.New("--dedupe-across-releases requires --all-tags"))
+		}
+		if allTags {
+			if splitDir != "" {
+				bail(errors.New("--split-output can't be combined with --all-tags"))
+			}
+			// sections are written out as they're generated rather than
+			// collected, unless they have to be merged into a file
+			var buf bytes.Buffer
+			var w io.Writer = os.Stdout
+			switch {
+			case prependPath != "" || appendPath != "":
+				w = &buf
+			case output != "":
+				f, err := os.Create(output)
+				if err != nil {
+					bail(errors.Wrapf(err, "failed to write %s", output))
+				}
+				defer f.Close()
+				w = f
+				if tee {
+					w = io.MultiWriter(f, os.Stdout)
+				}
+			}
 
-		taggedCommits, err := getTaggedCommits(repo)
-		bail(err)
+			// the table of contents goes first, so the sections are held
+			// back until every release is known
+			var contents tableOfContents
+			var sections bytes.Buffer
+			dest := w
+			if toc {
+				w = &sections
+			}
 
-		release := &Release{
-			Version: version,
-			Date:    date,
+			first := true
+			var changes []Change
+			emit := func(release *Release) error {
+				if outFormat.check != nil {
+					changes = append(changes, release.Changes...)
+				}
+				if toc {
+					contents.add(release)
+				}
+				if !first {
+					if _, err := io.WriteString(w, sectionSeparator(outFormat)); err != nil {
+						return err
+					}
+				}
+				first = false
+				if err := tmpl.Execute(w, release); err != nil {
+					return err
+				}
+				tmpl = next
+				return nil
+			}
+			if dedupeAcross {
+				// a change belongs to the oldest release listing it, which
+				// isn't known until the walk is over
+				var releases []*Release
+				err = gen.walk(head, version, tagName, true, func(release *Release) error {
+					releases = append(releases, release)
+					return nil
+				})
+				bail(err)
+				gen.dedupeAcrossReleases(releases)
+				for _, release := range releases {
+					bail(emit(release))
+				}
+			} else {
+				bail(gen.walk(head, version, tagName, true, emit))
+			}
+			if toc {
+				_, err = io.WriteString(dest, contents.String()+"\n")
+				bail(err)
+				_, err = sections.WriteTo(dest)
+				bail(err)
+			}
+
+			switch {
+			case prependPath != "":
+				bail(insertSection(prependPath, buf.Bytes(), insertPrepend))
+			case appendPath != "":
+				bail(insertSection(appendPath, buf.Bytes(), insertAppend))
+			}
+			if tee && buf.Len() > 0 {
+				_, err = buf.WriteTo(os.Stdout)
+				bail(err)
+			}
+			if outFormat.check != nil {
+				bail(outFormat.check(changes))
+			}
+			return
 		}
 
-		err = iter.ForEach(func(c *object.Commit) error {
-			var changeURL string
-			hashStr := c.Hash.String()
-			if _, ok := taggedCommits[hashStr]; ok {
-				if len(release.Changes) > 0 {
+		if gen.from != "" {
+			// an explicit range is a single release, whatever tags it spans
+			gen.taggedCommits = nil
+		}
+		var releases []*Release
+		if withUnreleased {
+			// the walk yields the commits made since the latest tag first,
+			// if there are any, followed by the tagged releases down to the
+			// given one, so none made since it are left out
+			err = gen.walk(head, "", "", true, func(r *Release) error {
+				releases = append(releases, r)
+				if r.Tag != "" && (tagName == "" || r.Tag == tagName) {
 					return ErrStopIteration
 				}
+				return nil
+			})
+			bail(err)
+			if tagName != "" && (len(releases) == 0 || releases[len(releases)-1].Tag != tagName) {
+				bail(errors.New(fmt.Sprintf("no release tagged %s", tagName)))
+			}
+		} else {
+			release := gen.finishHead(&Release{Version: version, Date: now().Format("2006-01-02")})
+			err = gen.walk(head, version, tagName, false, func(r *Release) error {
+				release = r
+				return ErrStopIteration
+			})
+			bail(err)
+			releases = []*Release{release}
+		}
+
+		if splitDir != "" {
+			if len(groupLevels) == 0 {
+				bail(errors.New("--split-output requires --group-by"))
 			}
-			if useURL { changeURL = remoteURL + "/commits/" + hashStr }
-			change := Change{
-				SHA:   hashStr[:7],
-				Title: strings.Split(c.Message, "\n")[0],
-				URL:   changeURL,
+			if withUnreleased {
+				bail(errors.New("--split-output can't be combined with --with-unreleased"))
 			}
-			release.Changes = append(release.Changes, change)
-			return nil
-		})
+			bail(writeSplitOutput(splitDir, outFormat.extension, tmpl, releases[0]))
+			return
+		}
 
-		if err != nil && err != ErrStopIteration {
-			bail(err)
+		var buf bytes.Buffer
+		var changes []Change
+		for i, release := range releases {
+			if i > 0 {
+				buf.WriteString(sectionSeparator(outFormat))
+			}
+			bail(tmpl.Execute(&buf, release))
+			tmpl = next
+			changes = append(changes, release.Changes...)
 		}
 
-		tmpl, err := template.New("release").Parse(releaseTemplate)
-		bail(err)
-		err = tmpl.Execute(os.Stdout, release)
-		bail(err)
+		switch {
+		case prependPath != "":
+			bail(insertSection(prependPath, buf.Bytes(), insertPrepend))
+		case appendPath != "":
+			bail(insertSection(appendPath, buf.Bytes(), insertAppend))
+		case output != "":
+			bail(writeOutput(output, buf.Bytes()))
+		default:
+			// without a file, stdout is the only destination
+			tee = true
+		}
+		if tee {
+			_, err = buf.WriteTo(os.Stdout)
+			bail(err)
+		}
+		if outFormat.check != nil {
+			bail(outFormat.check(changes))
+		}
 	},
 }
 
@@ -155,13 +558,15 @@ func parseRemoteURL(url string) (string, error) {
 	return repoURL, nil
 }
 
-func getTaggedCommits(repo *git.Repository) (map[string]bool, error) {
+// getTaggedCommits maps each tagged commit hash to the names of the tags
+// pointing at it, sorted for a stable choice when a commit has several.
+func getTaggedCommits(repo *git.Repository) (map[string][]string, error) {
 	tags, err := repo.Tags()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get tags")
 	}
 
-	tagCommitMap := make(map[string]bool)
+	tagCommitMap := make(map[string][]string)
 	err = tags.ForEach(func(ref *plumbing.Reference) error {
 		tag, err := repo.TagObject(ref.Hash())
 		var commitHash plumbing.Hash
@@ -170,10 +575,13 @@ func getTaggedCommits(repo *git.Repository) (map[string]bool, error) {
 		} else {
 			commitHash = ref.Hash()
 		}
-		tagCommitMap[commitHash.String()] = true
+		tagCommitMap[commitHash.String()] = append(tagCommitMap[commitHash.String()], ref.Name().Short())
 		return nil
 	})
 
+	for _, names := range tagCommitMap {
+		sort.Strings(names)
+	}
 	return tagCommitMap, err
 }
 
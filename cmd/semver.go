@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// semverTags drops the tags that aren't --tag-prefix followed by a semantic
+// version, such as rolling "nightly" or "latest" tags, so they never become
+// the boundary of a release. Commits left without tags are dropped too.
+func semverTags(tagged map[string][]string, prefix string) map[string][]string {
+	kept := make(map[string][]string, len(tagged))
+	for hash, names := range tagged {
+		var semverNames []string
+		for _, name := range names {
+			if isSemverTag(name, prefix) {
+				semverNames = append(semverNames, name)
+			}
+		}
+		if len(semverNames) > 0 {
+			kept[hash] = semverNames
+		}
+	}
+	return kept
+}
+
+// isSemverTag reports whether tag is prefix followed by a full semantic
+// version. The leading "v" of the version is optional, so both "v1.2.3" and
+// "1.2.3" are versions when the prefix is empty. Shorthands such as "v1" and
+// "v1.2" aren't, since they're the usual names of rolling major and minor
+// tags.
+func isSemverTag(tag, prefix string) bool {
+	if !strings.HasPrefix(tag, prefix) {
+		return false
+	}
+	version := strings.TrimPrefix(tag, prefix)
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	// Canonical fills in a missing minor and patch version and drops the
+	// build metadata, which doesn't make a version any less full
+	version, _, _ = strings.Cut(version, "+")
+	return semver.IsValid(version) && semver.Canonical(version) == version
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsSemverTag(t *testing.T) {
+	tests := []struct {
+		tag, prefix string
+		want        bool
+	}{
+		{"v1.2.3", "v", true},
+		{"v1.2.3-rc.1", "v", true},
+		{"v1.2.3+build.5", "v", true},
+		{"1.2.3", "", true},
+		{"v1.2.3", "", true},
+		{"api/v1.2.3", "api/v", true},
+		{"v1", "v", false},
+		{"v1.2", "v", false},
+		{"v0", "v", false},
+		{"nightly", "v", false},
+		{"latest", "", false},
+		{"v1.2.3", "api/v", false},
+		{"v1.2.3.4", "v", false},
+	}
+	for _, tt := range tests {
+		if got := isSemverTag(tt.tag, tt.prefix); got != tt.want {
+			t.Errorf("isSemverTag(%q, %q) = %v, want %v", tt.tag, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestMixedTags(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: first")
+	r.tag("v0.1.0")
+	r.commit("feat: second")
+	r.tag("v0.2.0")
+	r.commit("fix: third")
+	r.tag("v0")
+	r.tag("nightly")
+	r.commit("fix: fourth")
+	r.tag("v1")
+	r.tag("v1.0")
+	r.tag("latest")
+
+	tests := []struct {
+		name string
+		args []string
+		want [][]string
+	}{
+		{
+			name: "semver tags only",
+			want: [][]string{{"fix: fourth", "fix: third"}, {"feat: second"}, {"feat: first"}},
+		},
+		{
+			name: "every tag",
+			args: []string{"--include-nonsemver-tags"},
+			want: [][]string{{"fix: fourth"}, {"fix: third"}, {"feat: second"}, {"feat: first"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			var got [][]string
+			for _, release := range walkReleases(t, gen, head) {
+				got = append(got, titles(release.Changes))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import "strings"
+
+// unwrapSubject joins the lines of a message's first paragraph into one, for
+// --smart-subject, so a subject wrapped without a blank line before the body
+// isn't cut off after its first line. Messages whose first line is followed
+// by a blank line, as git recommends, are left alone.
+func unwrapSubject(message string) string {
+	subject, body, found := strings.Cut(message, "\n\n")
+	subject = strings.TrimRight(subject, "\n")
+	if !strings.Contains(subject, "\n") {
+		return message
+	}
+	lines := strings.Split(subject, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	subject = strings.Join(lines, " ")
+	if found {
+		return subject + "\n\n" + body
+	}
+	return subject
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import "testing"
+
+func TestUnwrapSubject(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"one line", "fix: a", "fix: a"},
+		{"trailing newline", "fix: a\n", "fix: a\n"},
+		{"standard", "fix: a\n\nbody\nmore", "fix: a\n\nbody\nmore"},
+		{"wrapped", "fix: a long\nsubject\n\nbody", "fix: a long subject\n\nbody"},
+		{"wrapped without a body", "fix: a long\n  subject\n", "fix: a long subject"},
+		{"wrapped over three lines", "fix: a\nvery long\nsubject\n\nbody\n\nmore", "fix: a very long subject\n\nbody\n\nmore"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unwrapSubject(tt.message); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSmartSubject(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a long subject\nthat wraps\n\nThe body.")
+
+	tests := []struct {
+		name        string
+		args        []string
+		title, body string
+	}{
+		{"off", nil, "feat: a long subject", "that wraps\n\nThe body."},
+		{"on", []string{"--smart-subject"}, "feat: a long subject that wraps", "The body."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, append([]string{"--with-body"}, tt.args...)...)
+			change := walkReleases(t, gen, head)[0].Changes[0]
+			if change.Title != tt.title || change.Body != tt.body {
+				t.Errorf("got %q with body %q, want %q with body %q", change.Title, change.Body, tt.title, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+)
+
+// submodule is a checked out submodule, for --include-submodules. Its repo
+// is nil when the submodule isn't checked out.
+type submodule struct {
+	repo      *git.Repository
+	remoteURL string
+}
+
+// openSubmodules opens the submodules listed in the worktree's .gitmodules,
+// keyed by their path.
+func openSubmodules(repo *git.Repository) (map[string]*submodule, error) {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, errors.Wrap(err, "--include-submodules needs a worktree")
+	}
+	subs, err := wt.Submodules()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read submodules")
+	}
+
+	opened := make(map[string]*submodule, len(subs))
+	for _, sm := range subs {
+		sub := &submodule{}
+		opened[sm.Config().Path] = sub
+		subRepo, err := sm.Repository()
+		if err != nil {
+			continue
+		}
+		sub.repo = subRepo
+		// commits are linked on the submodule's own remote
+		if rem, err := subRepo.Remote("origin"); err == nil && len(rem.Config().URLs) > 0 {
+			sub.remoteURL, _ = parseRemoteURL(rem.Config().URLs[0])
+		}
+	}
+	return opened, nil
+}
+
+// missingSubmodules lists the paths of the submodules that aren't checked
+// out, in order.
+func missingSubmodules(subs map[string]*submodule) []string {
+	var missing []string
+	for path, sub := range subs {
+		if sub.repo == nil {
+			missing = append(missing, path)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// submoduleChanges lists the commits a commit brought in by moving
+// submodule pointers, newest first. Pointers that were added, removed or
+// moved to commits the local clone of the submodule doesn't have are
+// skipped, as are submodules that aren't checked out.
+func (g *generator) submoduleChanges(c *object.Commit) []SubmoduleChanges {
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil
+		}
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return nil
+	}
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil
+	}
+
+	var bumps []SubmoduleChanges
+	for _, change := range changes {
+		from, to := change.From.TreeEntry, change.To.TreeEntry
+		if from.Mode != filemode.Submodule || to.Mode != filemode.Submodule {
+			continue
+		}
+		sub, ok := g.submodules[change.To.Name]
+		if !ok || sub.repo == nil {
+			continue
+		}
+		subChanges, err := g.submoduleRange(sub, from.Hash, to.Hash)
+		if err != nil || len(subChanges) == 0 {
+			continue
+		}
+		bumps = append(bumps, SubmoduleChanges{Path: change.To.Name, Changes: subChanges})
+	}
+	return bumps
+}
+
+// submoduleRange lists the submodule's commits reachable from to but not
+// from, the way the changes of a release are listed.
+func (g *generator) submoduleRange(sub *submodule, from, to plumbing.Hash) ([]Change, error) {
+	fromCommit, err := sub.repo.CommitObject(from)
+	if err != nil {
+		return nil, err
+	}
+	toCommit, err := sub.repo.CommitObject(to)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[plumbing.Hash]bool)
+	err = object.NewCommitPreorderIter(fromCommit, nil, nil).ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	err = object.NewCommitPreorderIter(toCommit, seen, nil).ForEach(func(c *object.Commit) error {
+		hashStr := c.Hash.String()
+		changes = append(changes, Change{
+			SHA:    hashStr[:g.abbrev.length],
+			Title:  strings.Split(commitMessage(c), "\n")[0],
+			URL:    commitURL(sub.remoteURL, hashStr),
+			Author: c.Author.Name,
+			Email:  c.Author.Email,
+			Date:   g.commitDate(c).Format("2006-01-02"),
+		})
+		return nil
+	})
+	return changes, err
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+)
+
+// submodule lists a submodule at path in .gitmodules, checks it out and
+// returns it as a repo of its own, whose origin is the URL gitmodules
+// gives it.
+func (r *testRepo) submodule(path string) *testRepo {
+	r.t.Helper()
+	r.writeFile(".gitmodules", gitmodules(path))
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	sm, err := wt.Submodule(path)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	if err := sm.Init(); err != nil {
+		r.t.Fatal(err)
+	}
+	repo, err := sm.Repository()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return &testRepo{t: r.t, dir: filepath.Join(r.dir, path), repo: repo, when: r.when}
+}
+
+// gitmodules lists submodules at paths in the .gitmodules format.
+func gitmodules(paths ...string) string {
+	var b strings.Builder
+	for _, path := range paths {
+		fmt.Fprintf(&b, "[submodule %q]\n\tpath = %s\n\turl = https://github.com/acme/%s.git\n", path, path, path)
+	}
+	return b.String()
+}
+
+// bumpSubmodule points the submodule at path to hash and commits it.
+func (r *testRepo) bumpSubmodule(path string, hash plumbing.Hash, message string) plumbing.Hash {
+	r.t.Helper()
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	entry, err := idx.Entry(path)
+	if err == index.ErrEntryNotFound {
+		entry = idx.Add(path)
+	} else if err != nil {
+		r.t.Fatal(err)
+	}
+	entry.Mode = filemode.Submodule
+	entry.Hash = hash
+	if err := r.repo.Storer.SetIndex(idx); err != nil {
+		r.t.Fatal(err)
+	}
+	return r.commit(message)
+}
+
+func TestIncludeSubmodules(t *testing.T) {
+	r := newTestRepo(t)
+	lib := r.submodule("lib")
+	first := lib.commit("feat: lib a")
+	r.bumpSubmodule("lib", first, "chore: add lib")
+	lib.commit("fix: lib b")
+	third := lib.commit("feat: lib c")
+	r.bumpSubmodule("lib", third, "chore: bump lib")
+	r.commit("docs: unrelated")
+
+	tests := []struct {
+		name string
+		args []string
+		want [][]string
+	}{
+		{"off", nil, [][]string{nil, nil, nil}},
+		// adding a submodule brings in no changes of its own
+		{"on", []string{"--include-submodules"}, [][]string{nil, {"lib", "feat: lib c", "fix: lib b"}, nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, head := testGenerator(t, r.dir, tt.args...)
+			var got [][]string
+			for _, change := range walkReleases(t, gen, head)[0].Changes {
+				var bumped []string
+				for _, sub := range change.Submodules {
+					bumped = append(append(bumped, sub.Path), titles(sub.Changes)...)
+				}
+				got = append(got, bumped)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	gen, head := testGenerator(t, r.dir, "--include-submodules")
+	url := walkReleases(t, gen, head)[0].Changes[1].Submodules[0].Changes[0].URL
+	if want := "https://github.com/acme/lib/commits/" + third.String(); url != want {
+		t.Errorf("got %q, want %q", url, want)
+	}
+
+	out := runSumit(t, r.dir, "--include-submodules", "1.0.0")
+	for _, want := range []string{"\n  - lib:\n    - feat: lib c [", "\n    - fix: lib b ["} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output doesn't list %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestMissingSubmodules(t *testing.T) {
+	r := newTestRepo(t)
+	r.submodule("lib")
+	r.writeFile(".gitmodules", gitmodules("lib", "vendor/b", "vendor/a"))
+
+	subs, err := openSubmodules(r.repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := missingSubmodules(subs), []string{"vendor/a", "vendor/b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
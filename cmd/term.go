@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// colorError is the ANSI SGR code used for error messages.
+const colorError = "31;1"
+
+// progressInterval is how many commits are processed between progress
+// updates, to avoid flooding the terminal on large repositories.
+const progressInterval = 100
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// useColor reports whether colorized output should be written to f. Color
+// is disabled by a non-empty NO_COLOR (https://no-color.org) and whenever f
+// isn't a terminal.
+func useColor(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(f)
+}
+
+// colorize wraps s in the given SGR code when f supports color.
+func colorize(f *os.File, code, s string) string {
+	if !useColor(f) {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+// progress reports how many commits have been walked, rewriting a single
+// line in place.
+type progress struct {
+	w       io.Writer
+	enabled bool
+	count   int
+}
+
+func newProgress(quiet bool) *progress {
+	return &progress{w: os.Stderr, enabled: !quiet && isTerminal(os.Stderr)}
+}
+
+func (p *progress) tick() {
+	p.count++
+	if p.enabled && p.count%progressInterval == 0 {
+		fmt.Fprintf(p.w, "\rprocessed %d commits", p.count)
+	}
+}
+
+// printf writes a message to stderr, clearing the progress line first so
+// the two don't run together.
+func (p *progress) printf(format string, args ...any) {
+	if p.enabled && p.count >= progressInterval {
+		fmt.Fprint(p.w, "\r\x1b[K")
+	}
+	fmt.Fprintf(p.w, format, args...)
+}
+
+// done clears the progress line so it doesn't mix with the output.
+func (p *progress) done() {
+	if p.enabled && p.count >= progressInterval {
+		fmt.Fprint(p.w, "\r\x1b[K")
+	}
+}
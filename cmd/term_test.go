@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestColorize(t *testing.T) {
+	// the null device is a character device, so it passes for a terminal
+	tty, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tty.Close()
+	file, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	tests := []struct {
+		name    string
+		f       *os.File
+		noColor string
+		want    string
+	}{
+		{"terminal", tty, "", "\x1b[31;1merror\x1b[0m"},
+		{"NO_COLOR", tty, "1", "error"},
+		{"file", file, "", "error"},
+		{"file with NO_COLOR", file, "1", "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			got := colorize(tt.f, colorError, "error")
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorsWithoutColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	out := runSumitError(t, t.TempDir(), "1.0.0")
+	if !strings.Contains(out, "error: ") || strings.Contains(out, "\x1b[") {
+		t.Errorf("got %q, want an error without escape codes", out)
+	}
+}
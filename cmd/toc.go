@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tableOfContents lists links to the headings of the releases added to it,
+// for --toc.
+type tableOfContents struct {
+	b    strings.Builder
+	used map[string]int
+}
+
+func (t *tableOfContents) add(release *Release) {
+	anchor := headingAnchor(release.Version + " - " + release.Date)
+	// repeated headings get numbered anchors, the first one keeping its own
+	if t.used == nil {
+		t.used = make(map[string]int)
+	}
+	if n := t.used[anchor]; n > 0 {
+		t.used[anchor]++
+		anchor = fmt.Sprintf("%s-%d", anchor, n)
+	} else {
+		t.used[anchor] = 1
+	}
+	fmt.Fprintf(&t.b, "- [%s](#%s)\n", release.Version, anchor)
+}
+
+func (t *tableOfContents) String() string {
+	return t.b.String()
+}
+
+// headingAnchor turns the text of a heading into the anchor GitHub and most
+// other markdown renderers link it by: lower-cased, without punctuation, and
+// with spaces replaced by dashes. Brackets around the version are dropped
+// along with the rest of the punctuation, so the anchor is the same with or
+// without --no-version-brackets.
+func headingAnchor(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHeadingAnchor(t *testing.T) {
+	tests := []struct {
+		heading, want string
+	}{
+		{"[1.2.0] - 2024-01-02", "120---2024-01-02"},
+		{"1.2.0 - 2024-01-02", "120---2024-01-02"},
+		{"v2.0.0-rc.1 - 2024-01-02", "v200-rc1---2024-01-02"},
+		{"Unreleased - 2024-06-01", "unreleased---2024-06-01"},
+		{"Ünïcode_Release - x", "ünïcode_release---x"},
+	}
+	for _, tt := range tests {
+		if got := headingAnchor(tt.heading); got != tt.want {
+			t.Errorf("headingAnchor(%q) = %q, want %q", tt.heading, got, tt.want)
+		}
+	}
+}
+
+func TestTableOfContents(t *testing.T) {
+	var toc tableOfContents
+	for _, version := range []string{"1.1.0", "1.0.0", "1.0.0", "1.0.0"} {
+		toc.add(&Release{Version: version, Date: "2024-01-01"})
+	}
+	want := []string{
+		"- [1.1.0](#110---2024-01-01)",
+		"- [1.0.0](#100---2024-01-01)",
+		"- [1.0.0](#100---2024-01-01-1)",
+		"- [1.0.0](#100---2024-01-01-2)",
+	}
+	if got := lines(toc.String()); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTOCFlag(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a")
+	r.tag("v1.0.0")
+	r.commit("fix: b")
+	r.tag("v1.1.0")
+
+	out := runSumit(t, r.dir, "--all-tags", "--toc")
+	want := "- [1.1.0](#110---2024-01-01)\n- [1.0.0](#100---2024-01-01)\n\n## [1.1.0] - 2024-01-01\n"
+	if !strings.HasPrefix(out, want) {
+		t.Errorf("got\n%s\nwant it to start with\n%s", out, want)
+	}
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var signoffPattern = regexp.MustCompile(`(?mi)^Signed-off-by:[ \t]*\S`)
+
+var trailerPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*):\s*(.*)$`)
+
+// parseTrailers extracts the "Key: Value" trailers from the last paragraph
+// of a commit message. Like git, the paragraph only counts as a trailer
+// block when every line is a trailer or a whitespace-indented continuation
+// of the previous one, and the subject paragraph never does.
+func parseTrailers(message string) map[string][]string {
+	paragraphs := strings.Split(strings.TrimSpace(message), "\n\n")
+	if len(paragraphs) < 2 {
+		return nil
+	}
+	block := strings.Trim(paragraphs[len(paragraphs)-1], "\n")
+
+	trailers := make(map[string][]string)
+	var lastKey string
+	for _, line := range strings.Split(block, "\n") {
+		if lastKey != "" && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			values := trailers[lastKey]
+			values[len(values)-1] += " " + strings.TrimSpace(line)
+			continue
+		}
+		m := trailerPattern.FindStringSubmatch(line)
+		if m == nil {
+			return nil
+		}
+		lastKey = m[1]
+		trailers[lastKey] = append(trailers[lastKey], strings.TrimSpace(m[2]))
+	}
+	return trailers
+}
+
+// trailerValue returns the last value of a trailer, matching the key
+// case-insensitively as git does. When the key appears with several
+// spellings, the exact one wins, then the first in sorted order, so the
+// result doesn't depend on map iteration order.
+func trailerValue(trailers map[string][]string, key string) (string, bool) {
+	if values := trailers[key]; len(values) > 0 {
+		return values[len(values)-1], true
+	}
+	keys := make([]string, 0, len(trailers))
+	for k := range trailers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if values := trailers[k]; strings.EqualFold(k, key) && len(values) > 0 {
+			return values[len(values)-1], true
+		}
+	}
+	return "", false
+}
+
+// hasSignoff reports whether any line of the message is a Signed-off-by
+// trailer. Unlike parseTrailers this doesn't insist on the last paragraph,
+// since sign-offs are often followed by other notes or squashed together
+// from several commits.
+func hasSignoff(message string) bool {
+	return signoffPattern.MatchString(message)
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTrailers(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    map[string][]string
+	}{
+		{"subject only", "Changelog: fix", nil},
+		{"no trailer block", "feat: a\n\nSome body text.", nil},
+		{"trailers", "feat: a\n\nbody\n\nChangelog: security\nReviewed-by: Bob", map[string][]string{
+			"Changelog":   {"security"},
+			"Reviewed-by": {"Bob"},
+		}},
+		{"repeated key", "feat: a\n\nCo-authored-by: A\nCo-authored-by: B", map[string][]string{
+			"Co-authored-by": {"A", "B"},
+		}},
+		{"continuation", "feat: a\n\nNote: first\n  second", map[string][]string{
+			"Note": {"first second"},
+		}},
+		{"mixed paragraph", "feat: a\n\nChangelog: fix\nnot a trailer", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseTrailers(tt.message); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrailerValue(t *testing.T) {
+	trailers := map[string][]string{
+		"changelog": {"lower"},
+		"Note":      {"first", "last"},
+	}
+	tests := []struct {
+		key, want string
+		ok        bool
+	}{
+		{"Note", "last", true},
+		{"note", "last", true},
+		{"changelog", "lower", true},
+		{"Changelog", "lower", true},
+		{"Missing", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := trailerValue(trailers, tt.key)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("trailerValue(%q) = %q, %v, want %q, %v", tt.key, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCategoryTrailer(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: categorized\n\nChangelog: Security")
+	r.commit("fix: uncategorized")
+	r.commit("plain subject\n\nchangelog: docs")
+	r.commit("feat: custom\n\nCategory: perf")
+
+	tests := []struct {
+		name    string
+		args    []string
+		include string
+		want    []string
+	}{
+		{"default key", nil, "security,docs", []string{"plain subject", "feat: categorized"}},
+		{"custom key", []string{"--category-trailer", "Category"}, "perf", []string{"feat: custom"}},
+		{"disabled", []string{"--category-trailer", ""}, "feat", []string{"feat: custom", "feat: categorized"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append(tt.args, "--include-types", tt.include, "1.0.0")
+			if got := changeTitles(runSumit(t, r.dir, args...)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrailersInTemplate(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: reviewed\n\nBody text.\n\nReviewed-by: Bob\nReviewed-by: Carol\nFixes: #12")
+	r.commit("fix: unreviewed")
+
+	out := runSumit(t, r.dir, "--commit-template", `- {{ .Title }}{{ range index .Trailers "Reviewed-by" }} (reviewed by {{ . }}){{ end }}{{ with .Trailers.Fixes }} fixes {{ index . 0 }}{{ end }}`, "1.0.0")
+	for _, want := range []string{
+		"- feat: reviewed (reviewed by Bob) (reviewed by Carol) fixes #12\n",
+		"- fix: unreviewed\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output has no %q:\n%s", want, out)
+		}
+	}
+}
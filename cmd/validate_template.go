@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	validateTemplateCmd.Flags().Bool("parse-only", false, "Only parse the template without rendering a sample release")
+	rootCmd.AddCommand(validateTemplateCmd)
+}
+
+var validateTemplateCmd = &cobra.Command{
+	Use:   "validate-template <file>",
+	Short: "Check that a custom template parses and renders",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := args[0]
+		text, err := readTemplate(cmd, path)
+		bail(err)
+
+		format, _ := cmd.Flags().GetString("output-format")
+		engine, _ := cmd.Flags().GetString("template-engine")
+		tmpl, err := parseTemplate(format, engine, text, "")
+		if err != nil {
+			bail(errors.Wrap(err, "failed to parse template"))
+		}
+
+		parseOnly, _ := cmd.Flags().GetBool("parse-only")
+		if !parseOnly {
+			if err := tmpl.Execute(io.Discard, sampleRelease()); err != nil {
+				bail(errors.Wrap(err, "failed to render sample release"))
+			}
+		}
+		fmt.Printf("%s: ok\n", path)
+	},
+}
+
+// sampleRelease is a small release with every field set, used to catch
+// templates that fail at render time, for example by referencing a field
+// that doesn't exist.
+func sampleRelease() *Release {
+	changes := []Change{
+		{
+			SHA:    "1a2b3c4",
+			Title:  "feat(api): add pagination (#42)",
+			URL:    "https://github.com/acme/widget/commits/1a2b3c4d5e6f",
+			Author: "Jane Doe",
+			Email:  "jane@example.com",
+			Date:   "2024-01-01",
+			Type:   "feat",
+			Scope:  "api",
+			PR:     42,
+			Body:   "Lists now return a cursor for the next page.",
+			Issues: []Issue{{Key: "API-7", URL: "https://jira.example.com/browse/API-7"}},
+			Stats:  &DiffStats{Files: 3, Insertions: 42, Deletions: 7},
+			Branch: "v1.2.0",
+		},
+		{
+			SHA:      "5d6e7f8",
+			Title:    "fix!: reject empty names",
+			URL:      "https://github.com/acme/widget/commits/5d6e7f8a9b0c",
+			Author:   "John Roe",
+			Email:    "john@example.com",
+			Date:     "2024-01-02",
+			Type:     "fix",
+			Breaking: true,
+			Commits:  []string{"validate names", "update tests"},
+		},
+	}
+	return &Release{
+		Version:     "1.1.0",
+		Date:        "2024-01-02",
+		PreviousTag: "v1.0.0",
+		Tagger:      "Jane Doe",
+		TaggerDate:  "2024-01-02",
+		CompareURL:  "https://github.com/acme/widget/compare/v1.0.0...v1.1.0",
+		Changes:     changes,
+		Groups: []Group{
+			{Title: "Features", Changes: changes[:1]},
+			{Title: "Bug Fixes", Changes: changes[1:]},
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateTemplate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, text string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	good := write("good.tmpl", "{{ range .Changes }}{{ .Title }} {{ link .SHA .URL }}\n{{ end }}")
+	unknownField := write("field.tmpl", "{{ range .Changes }}{{ .Nope }}{{ end }}")
+	unclosed := write("unclosed.tmpl", "{{ range .Changes }}")
+
+	if got, want := runSumit(t, dir, "validate-template", good), good+": ok\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := runSumit(t, dir, "validate-template", "--parse-only", unknownField), unknownField+": ok\n"; got != want {
+		t.Errorf("with --parse-only got %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		path, want string
+	}{
+		{unknownField, "failed to render sample release"},
+		{unclosed, "failed to parse template"},
+		{filepath.Join(dir, "missing.tmpl"), "failed to read template"},
+	}
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.path), func(t *testing.T) {
+			if out := runSumitError(t, dir, "validate-template", tt.path); !strings.Contains(out, tt.want) {
+				t.Errorf("got %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var blankLinesPattern = regexp.MustCompile(`\n(?:[ \t]*\n){2,}`)
+
+// normalizeRenderer tidies the blank lines of whatever the wrapped renderer
+// produces, for --normalize-whitespace.
+type normalizeRenderer struct {
+	renderer
+}
+
+func (r normalizeRenderer) Execute(w io.Writer, data any) error {
+	var buf bytes.Buffer
+	if err := r.renderer.Execute(&buf, data); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, normalizeWhitespace(buf.String()))
+	return err
+}
+
+// normalizeWhitespace collapses runs of blank lines into a single one and
+// trims blank lines from both ends, leaving a single trailing newline, as
+// markdownlint's MD012 and MD047 rules expect.
+func normalizeWhitespace(text string) string {
+	text = blankLinesPattern.ReplaceAllString(text, "\n\n")
+	text = strings.Trim(text, "\n")
+	if text == "" {
+		return ""
+	}
+	return text + "\n"
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeWhitespace(t *testing.T) {
+	tests := []struct {
+		name, text, want string
+	}{
+		{"already tidy", "## 1.0.0\n\n- a\n", "## 1.0.0\n\n- a\n"},
+		{"runs of blank lines", "## 1.0.0\n\n\n\n- a\n\n\n- b\n", "## 1.0.0\n\n- a\n\n- b\n"},
+		{"blank lines with spaces", "- a\n  \n\t\n- b", "- a\n\n- b\n"},
+		{"leading and trailing", "\n\n## 1.0.0\n- a\n\n\n", "## 1.0.0\n- a\n"},
+		{"indentation kept", "\n  - nested\n", "  - nested\n"},
+		{"empty", "\n\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeWhitespace(tt.text); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeWhitespaceFlag(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: a")
+	r.tag("v1.0.0")
+	r.commit("fix: b")
+
+	out := runSumit(t, r.dir, "--normalize-whitespace", "--all-tags")
+	if strings.Contains(out, "\n\n\n") || strings.HasPrefix(out, "\n") || !strings.HasSuffix(out, "\n") || strings.HasSuffix(out, "\n\n") {
+		t.Errorf("output isn't normalized:\n%q", out)
+	}
+}
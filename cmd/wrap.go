@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	bulletPattern = regexp.MustCompile(`^(\s*(?:[-*•]|\d+\.) )(.*)$`)
+	mdLinkPattern = regexp.MustCompile(`\[[^\]]*\]\([^)]*\)`)
+)
+
+// linkSpacePlaceholder stands in for the spaces inside a link while a line
+// is split into words.
+const linkSpacePlaceholder = "\x00"
+
+// wrapRenderer hard-wraps the bullet lines of whatever the wrapped renderer
+// produces, for --wrap.
+type wrapRenderer struct {
+	renderer
+	width int
+}
+
+func (r wrapRenderer) Execute(w io.Writer, data any) error {
+	var buf bytes.Buffer
+	if err := r.renderer.Execute(&buf, data); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, wrapBullets(buf.String(), r.width))
+	return err
+}
+
+// wrapBullets wraps every bullet line in text to width columns, indenting
+// the continuation lines to line up with the text after the bullet. Other
+// lines are left alone. Columns are counted in runes, and markdown links are
+// never broken, so a line can end up longer than width when a single word or
+// link is.
+func wrapBullets(text string, width int) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if utf8.RuneCountInString(line) <= width {
+			continue
+		}
+		m := bulletPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		lines[i] = wrapLine(m[1], m[2], width)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func wrapLine(bullet, text string, width int) string {
+	text = mdLinkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		return strings.ReplaceAll(link, " ", linkSpacePlaceholder)
+	})
+	hanging := strings.Repeat(" ", utf8.RuneCountInString(bullet))
+
+	var b strings.Builder
+	b.WriteString(bullet)
+	col := utf8.RuneCountInString(bullet)
+	lineStart := true
+	for _, word := range strings.Fields(text) {
+		word = strings.ReplaceAll(word, linkSpacePlaceholder, " ")
+		n := utf8.RuneCountInString(word)
+		if !lineStart && col+1+n > width {
+			b.WriteString("\n" + hanging)
+			col = len(hanging)
+			lineStart = true
+		}
+		if !lineStart {
+			b.WriteString(" ")
+			col++
+		}
+		b.WriteString(word)
+		col += n
+		lineStart = false
+	}
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWrapBullets(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  string
+	}{
+		{
+			name:  "short line",
+			text:  "- fix: short",
+			width: 20,
+			want:  "- fix: short",
+		},
+		{
+			name:  "long subject",
+			text:  "- feat: add a long subject that needs wrapping",
+			width: 20,
+			want:  "- feat: add a long\n  subject that needs\n  wrapping",
+		},
+		{
+			name:  "link kept whole",
+			text:  "- fix: crash [see the docs](https://example.com/a b) now",
+			width: 20,
+			want:  "- fix: crash\n  [see the docs](https://example.com/a b)\n  now",
+		},
+		{
+			name:  "multi-byte runes",
+			text:  "- überall ändern öfter",
+			width: 16,
+			want:  "- überall ändern\n  öfter",
+		},
+		{
+			name:  "nested and numbered bullets",
+			text:  "  * one two three four\n1. one two three four",
+			width: 12,
+			want:  "  * one two\n    three\n    four\n1. one two\n   three\n   four",
+		},
+		{
+			name:  "headings left alone",
+			text:  "## [1.0.0] - 2024-01-01 with a long heading",
+			width: 10,
+			want:  "## [1.0.0] - 2024-01-01 with a long heading",
+		},
+		{
+			name:  "word longer than width",
+			text:  "- supercalifragilistic word",
+			width: 10,
+			want:  "- supercalifragilistic\n  word",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapBullets(tt.text, tt.width); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapFlag(t *testing.T) {
+	r := newTestRepo(t)
+	r.remote("origin", "https://github.com/acme/widget.git")
+	r.commit("feat: add a fairly long subject so that the rendered bullet has to wrap")
+
+	out := runSumit(t, r.dir, "--wrap", "40", "1.0.0")
+	for _, line := range lines(out) {
+		if strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "- ") {
+			// the link to the commit is longer than the width on its own
+			if n := utf8.RuneCountInString(line); n > 40 && !strings.Contains(line, "](") {
+				t.Errorf("line %q has %d columns", line, n)
+			}
+		}
+	}
+	if !strings.Contains(out, "\n  ") {
+		t.Errorf("output isn't wrapped:\n%s", out)
+	}
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlRenderer writes a release as a YAML document with the same fields,
+// in the same order, as its JSON encoding. The data model's yaml tags match
+// its json tags, so the document decodes back into a Release.
+type yamlRenderer struct{}
+
+func (yamlRenderer) Execute(w io.Writer, data any) error {
+	// JSON is valid YAML, so decoding it into a node keeps the field order
+	// and names of the json tags
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(encoded, &doc); err != nil {
+		return err
+	}
+	blockStyle(&doc)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle clears the flow and quoting styles a node picked up from JSON,
+// so it's encoded as block YAML with quotes only where they're needed.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestYAMLRoundTrip(t *testing.T) {
+	release := sampleRelease()
+	release.Tag = "v1.1.0"
+	release.Context = map[string]string{"project": "widget"}
+	release.Domains = []DomainCount{{Domain: "example.com", Count: 2}}
+	release.Changes[0].Trailers = map[string][]string{"Reviewed-by": {"John Roe"}}
+	release.Changes[0].SignedOff = true
+	release.Changes[0].Conventional = true
+	release.Changes[1].Issues = []Issue{{Key: "WID-7", URL: "https://acme.atlassian.net/browse/WID-7"}}
+
+	var buf bytes.Buffer
+	if err := (yamlRenderer{}).Execute(&buf, release); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"previous_tag:", "compare_url:", "tagger_date:", "signed_off:"} {
+		if !strings.Contains(buf.String(), key) {
+			t.Errorf("output has no %s key:\n%s", key, buf.String())
+		}
+	}
+
+	var decoded Release
+	if err := yaml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&decoded, release) {
+		t.Errorf("decoded\n%+v\nwant\n%+v", decoded, *release)
+	}
+}